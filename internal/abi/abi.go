@@ -0,0 +1,9 @@
+// Package abi embeds the ABIs of the contracts known to the server
+package abi
+
+import _ "embed"
+
+// SimplePersonInfo is the JSON ABI of the SimplePersonInfo contract
+//
+//go:embed SimplePersonInfo.abi
+var SimplePersonInfo string
@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"fmt"
+	"slices"
+)
+
+// apiKeyPrefix marks API keys, so they are recognizable in configs and secret scanners
+const apiKeyPrefix = "lime_"
+
+// apiKeyDisplayLength is how many characters of a key are kept to identify it in listings
+const apiKeyDisplayLength = len(apiKeyPrefix) + 8
+
+// Scopes API keys can be granted. Access tokens of a user are not limited by scopes.
+const (
+	ScopeTransactionsRead = "transactions:read"
+	ScopeABIsWrite        = "abis:write"
+)
+
+// Scopes lists all known scopes
+var Scopes = []string{ScopeTransactionsRead, ScopeABIsWrite}
+
+// NewAPIKey returns a random API key, the prefix identifying it in listings and the hash to store in its place
+func NewAPIKey() (key, prefix, hash string, err error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	key = apiKeyPrefix + token
+	return key, key[:apiKeyDisplayLength], HashToken(key), nil
+}
+
+// ValidateScopes checks that all scopes are known
+func ValidateScopes(scopes []string) error {
+	for _, scope := range scopes {
+		if !slices.Contains(Scopes, scope) {
+			return fmt.Errorf("unknown scope %q", scope)
+		}
+	}
+	return nil
+}
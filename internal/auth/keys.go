@@ -0,0 +1,251 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// SigningKey is a key tokens are signed or verified with.
+// Keys without a private part can only verify tokens, which is how retired keys are kept
+// around until the tokens they signed expire.
+type SigningKey struct {
+	ID     string
+	Method jwt.SigningMethod
+
+	signKey   any
+	verifyKey any
+	// notAfter, if set, is the latest expiry of tokens the key verifies
+	notAfter time.Time
+}
+
+// CanSign reports whether the key holds a private part
+func (k *SigningKey) CanSign() bool {
+	return k.signKey != nil
+}
+
+// KeySet holds the keys tokens are verified with and the active key new tokens are signed with
+type KeySet struct {
+	keys   map[string]*SigningKey
+	active *SigningKey
+}
+
+// NewHMACKeySet returns a KeySet signing and verifying tokens with a shared secret
+func NewHMACKeySet(secret string) (*KeySet, error) {
+	if secret == "" {
+		return nil, errors.New("JWT secret must not be empty")
+	}
+
+	key := newHMACKey(secret)
+	return &KeySet{keys: map[string]*SigningKey{key.ID: key}, active: key}, nil
+}
+
+// LoadKeySet loads the PEM encoded RSA and P-256 ECDSA keys of a directory. The ID of each key
+// is its file name without the .pem extension. New tokens are signed with the key activeKID,
+// which may be omitted if the directory holds a single private key.
+//
+// If legacySecret is set, tokens signed with the shared secret before asymmetric keys were
+// configured are still accepted if they expire by legacyUntil, so switching doesn't log everyone
+// out. Once legacyUntil has passed, the shared secret can't be used to forge tokens anymore.
+func LoadKeySet(dir, activeKID, legacySecret string, legacyUntil time.Time) (*KeySet, error) {
+	if legacySecret != "" && legacyUntil.IsZero() {
+		return nil, errors.New("the cutoff of the legacy secret must be set")
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	set := &KeySet{keys: make(map[string]*SigningKey)}
+	var signers []*SigningKey
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		// Tokens without a key ID are verified with the legacy secret, so key files must name one
+		id := strings.TrimSuffix(filepath.Base(path), ".pem")
+		if id == "" {
+			return nil, fmt.Errorf("%s: key file name must not be empty", path)
+		}
+
+		key, err := parseKey(id, data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		set.keys[key.ID] = key
+		if key.CanSign() {
+			signers = append(signers, key)
+		}
+	}
+
+	switch {
+	case activeKID != "":
+		set.active = set.keys[activeKID]
+		if set.active == nil || !set.active.CanSign() {
+			return nil, fmt.Errorf("no private key with ID %q in %s", activeKID, dir)
+		}
+	case len(signers) == 1:
+		set.active = signers[0]
+	default:
+		return nil, fmt.Errorf("found %d private keys in %s, the active key ID must be set", len(signers), dir)
+	}
+
+	if legacySecret != "" {
+		key := newHMACKey(legacySecret)
+		key.notAfter = legacyUntil
+		set.keys[key.ID] = key
+	}
+
+	return set, nil
+}
+
+// newHMACKey returns a key for a shared secret. It has no ID, matching the tokens
+// issued before key IDs were introduced.
+func newHMACKey(secret string) *SigningKey {
+	return &SigningKey{
+		Method:    jwt.SigningMethodHS256,
+		signKey:   []byte(secret),
+		verifyKey: []byte(secret),
+	}
+}
+
+// parseKey parses a PEM encoded private or public key
+func parseKey(id string, data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	var parsed any
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		parsed, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &SigningKey{ID: id}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.signKey, key.verifyKey = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method, key.verifyKey = jwt.SigningMethodRS256, k
+	case *ecdsa.PrivateKey:
+		key.Method, key.signKey, key.verifyKey = jwt.SigningMethodES256, k, &k.PublicKey
+	case *ecdsa.PublicKey:
+		key.Method, key.verifyKey = jwt.SigningMethodES256, k
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+
+	if ecKey, ok := key.verifyKey.(*ecdsa.PublicKey); ok && ecKey.Curve != elliptic.P256() {
+		return nil, errors.New("only P-256 ECDSA keys are supported")
+	}
+	if rsaKey, ok := key.verifyKey.(*rsa.PublicKey); ok && rsaKey.N.BitLen() < 2048 {
+		return nil, errors.New("RSA keys must be at least 2048 bits")
+	}
+
+	return key, nil
+}
+
+// sign signs the token with the active key
+func (s *KeySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(s.active.Method, claims)
+	if s.active.ID != "" {
+		token.Header["kid"] = s.active.ID
+	}
+	return token.SignedString(s.active.signKey)
+}
+
+// verifyKey resolves the key a token was signed with from its key ID and algorithm
+func (s *KeySet) verifyKey(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := s.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+	// Never let the token choose the algorithm, e.g. verifying HS256 with a public key as secret
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %s for key %q", token.Method.Alg(), kid)
+	}
+	if !key.notAfter.IsZero() {
+		claims, ok := token.Claims.(*Claims)
+		if !ok || claims.ExpiresAt == nil || claims.ExpiresAt.After(key.notAfter) {
+			return nil, fmt.Errorf("key %q only verifies tokens expiring by %s", kid, key.notAfter.Format(time.RFC3339))
+		}
+	}
+	return key.verifyKey, nil
+}
+
+// JWK is a public key in JSON Web Key format
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// EC
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of the set. Shared secrets are never published.
+func (s *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	for _, key := range s.keys {
+		jwk := JWK{KeyID: key.ID, Use: "sig", Algorithm: key.Method.Alg()}
+		switch k := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(k.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes())
+		case *ecdsa.PublicKey:
+			jwk.KeyType = "EC"
+			jwk.Curve = "P-256"
+			jwk.X = base64.RawURLEncoding.EncodeToString(k.X.FillBytes(make([]byte, 32)))
+			jwk.Y = base64.RawURLEncoding.EncodeToString(k.Y.FillBytes(make([]byte, 32)))
+		default:
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	sort.Slice(jwks.Keys, func(i, j int) bool { return jwks.Keys[i].KeyID < jwks.Keys[j].KeyID })
+	return jwks
+}
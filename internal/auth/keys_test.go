@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// writeKey writes a PKCS8 encoded private key to dir/id.pem
+func writeKey(t *testing.T, dir, id string, key any) {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, id+".pem"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	writeKey(t, dir, "2024-01", rsaKey)
+	writeKey(t, dir, "2024-02", ecKey)
+
+	if _, err := LoadKeySet(dir, "", "", time.Time{}); err == nil {
+		t.Error("expected an error when the active key is ambiguous")
+	}
+
+	newManager := func(activeKID string) *TokenManager {
+		keys, err := LoadKeySet(dir, activeKID, "secret", time.Now().Add(time.Hour))
+		if err != nil {
+			t.Fatalf("LoadKeySet() returned error: %v", err)
+		}
+		manager, err := NewTokenManager(keys, "issuer", "audience", time.Minute, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return manager
+	}
+
+	before := newManager("2024-01")
+	after := newManager("2024-02")
+	legacy := newTestTokenManager(t)
+
+	for name, issuer := range map[string]*TokenManager{"RS256": before, "ES256": after, "legacy HS256": legacy} {
+		token, err := issuer.Issue(1, "alice")
+		if err != nil {
+			t.Fatalf("%s: Issue() returned error: %v", name, err)
+		}
+		if _, err := after.Parse(token); err != nil {
+			t.Errorf("%s: expected token to verify after rotation, got %v", name, err)
+		}
+	}
+
+	jwks := after.JWKS()
+	if len(jwks.Keys) != 2 || jwks.Keys[0].KeyType != "RSA" || jwks.Keys[1].KeyType != "EC" {
+		t.Errorf("expected the RSA and EC public keys to be published, got %+v", jwks.Keys)
+	}
+
+	// A token signed with HS256 using the public key as secret must not verify
+	publicDER, _ := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{RegisteredClaims: jwt.RegisteredClaims{
+		ID:        "forged",
+		Subject:   "1",
+		Issuer:    "issuer",
+		Audience:  jwt.ClaimStrings{"audience"},
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	}})
+	forged.Header["kid"] = "2024-01"
+	forgedToken, err := forged.SignedString(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := after.Parse(forgedToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected algorithm confusion to be rejected, got %v", err)
+	}
+}
+
+func TestLegacySecretCutoff(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	writeKey(t, dir, "2024-01", ecKey)
+
+	if _, err := LoadKeySet(dir, "", "secret", time.Time{}); err == nil {
+		t.Error("expected an error for a legacy secret without cutoff")
+	}
+
+	newManager := func(keys *KeySet, accessTTL time.Duration) *TokenManager {
+		manager, err := NewTokenManager(keys, "issuer", "audience", accessTTL, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return manager
+	}
+	legacyKeys, _ := NewHMACKeySet("secret")
+	shortLived, _ := newManager(legacyKeys, 10*time.Second).Issue(1, "alice")
+	longLived, _ := newManager(legacyKeys, time.Hour).Issue(1, "alice")
+
+	keys, err := LoadKeySet(dir, "", "secret", time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("LoadKeySet() returned error: %v", err)
+	}
+	manager := newManager(keys, time.Minute)
+	if _, err := manager.Parse(shortLived); err != nil {
+		t.Errorf("expected a legacy token expiring before the cutoff to verify, got %v", err)
+	}
+	// Tokens minted with the shared secret can't outlive the cutoff
+	if _, err := manager.Parse(longLived); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected a legacy token expiring after the cutoff to be rejected, got %v", err)
+	}
+
+	keys, err = LoadKeySet(dir, "", "", time.Time{})
+	if err != nil {
+		t.Fatalf("LoadKeySet() returned error: %v", err)
+	}
+	if _, err := newManager(keys, time.Minute).Parse(shortLived); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected legacy tokens to be rejected without a legacy secret, got %v", err)
+	}
+
+	// A key file without a name would collide with the legacy secret, which has no key ID
+	writeKey(t, dir, "", ecKey)
+	if _, err := LoadKeySet(dir, "2024-01", "", time.Time{}); err == nil {
+		t.Error("expected an error for a key file without a name")
+	}
+}
@@ -0,0 +1,238 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AttemptState tracks the failed login attempts of a username or client IP
+type AttemptState struct {
+	Failures    int
+	LastFailure time.Time
+	LockedUntil time.Time
+	// ExpiresAt is when the state can be forgotten
+	ExpiresAt time.Time
+}
+
+// AttemptStore stores failed login attempts. Implementations must be safe for concurrent use.
+type AttemptStore interface {
+	// Get returns the state of the key, or a zero state if it is unknown or expired
+	Get(ctx context.Context, key string, now time.Time) (AttemptState, error)
+	Put(ctx context.Context, key string, state AttemptState) error
+	Delete(ctx context.Context, key string) error
+}
+
+// LockoutPolicy configures when and for how long a key is locked out
+type LockoutPolicy struct {
+	// Threshold is the number of failures after which the key is locked out
+	Threshold int
+	// BaseDelay is the lockout after reaching the threshold; it doubles with each further failure
+	BaseDelay time.Duration
+	// MaxDelay caps the lockout
+	MaxDelay time.Duration
+	// ResetAfter is how long after the last failure the failures are forgotten
+	ResetAfter time.Duration
+}
+
+// lockout returns how long a key is locked out after the given number of failures
+func (p LockoutPolicy) lockout(failures int) time.Duration {
+	if failures < p.Threshold {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := p.Threshold; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, p.MaxDelay)
+}
+
+// DefaultUsernamePolicy locks out a username after 5 failures, for up to 15 minutes
+var DefaultUsernamePolicy = LockoutPolicy{
+	Threshold:  5,
+	BaseDelay:  30 * time.Second,
+	MaxDelay:   15 * time.Minute,
+	ResetAfter: time.Hour,
+}
+
+// DefaultIPPolicy locks out a client IP after 20 failures, for up to an hour.
+// It is more lenient than the username policy because many users can share an IP.
+var DefaultIPPolicy = LockoutPolicy{
+	Threshold:  20,
+	BaseDelay:  time.Minute,
+	MaxDelay:   time.Hour,
+	ResetAfter: time.Hour,
+}
+
+// LoginLimiter locks out usernames and client IPs with exponentially growing delays after
+// repeated failed logins. Unknown usernames are tracked like existing ones, so lockouts
+// don't reveal whether a username exists.
+type LoginLimiter struct {
+	store          AttemptStore
+	usernamePolicy LockoutPolicy
+	ipPolicy       LockoutPolicy
+	now            func() time.Time
+
+	// mu serializes the read-modify-write of failures, so concurrent guesses aren't lost
+	mu sync.Mutex
+}
+
+// LimiterOption configures a LoginLimiter
+type LimiterOption func(*LoginLimiter)
+
+// WithClock makes the LoginLimiter read the current time from now instead of time.Now
+func WithClock(now func() time.Time) LimiterOption {
+	return func(l *LoginLimiter) {
+		l.now = now
+	}
+}
+
+// NewLoginLimiter creates a LoginLimiter tracking attempts in the store
+func NewLoginLimiter(store AttemptStore, usernamePolicy, ipPolicy LockoutPolicy, opts ...LimiterOption) *LoginLimiter {
+	l := &LoginLimiter{
+		store:          store,
+		usernamePolicy: usernamePolicy,
+		ipPolicy:       ipPolicy,
+		now:            time.Now,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+func usernameKey(username string) string {
+	return "username:" + strings.ToLower(username)
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Reserve atomically checks whether logins of the username from the IP are locked out and,
+// if they are not, counts the attempt as failed until Succeed is called. Checking and counting
+// at once means concurrent guesses can't all pass the check before any of them is counted.
+// It returns how long logins are locked out, or zero if the attempt may proceed.
+func (l *LoginLimiter) Reserve(ctx context.Context, username, ip string) (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	var retryAfter time.Duration
+	for _, key := range []string{usernameKey(username), ipKey(ip)} {
+		state, err := l.store.Get(ctx, key, now)
+		if err != nil {
+			return 0, err
+		}
+		retryAfter = max(retryAfter, state.LockedUntil.Sub(now))
+	}
+	if retryAfter > 0 {
+		return retryAfter, nil
+	}
+
+	if err := l.fail(ctx, usernameKey(username), l.usernamePolicy, now); err != nil {
+		return 0, err
+	}
+	return 0, l.fail(ctx, ipKey(ip), l.ipPolicy, now)
+}
+
+func (l *LoginLimiter) fail(ctx context.Context, key string, policy LockoutPolicy, now time.Time) error {
+	state, err := l.store.Get(ctx, key, now)
+	if err != nil {
+		return err
+	}
+	if now.Sub(state.LastFailure) > policy.ResetAfter {
+		state = AttemptState{}
+	}
+
+	state.Failures++
+	state.LastFailure = now
+	if lockout := policy.lockout(state.Failures); lockout > 0 {
+		state.LockedUntil = now.Add(lockout)
+	}
+	state.ExpiresAt = now.Add(max(policy.ResetAfter, state.LockedUntil.Sub(now)))
+
+	return l.store.Put(ctx, key, state)
+}
+
+// Succeed records that the reserved login of the username from the IP succeeded. The failed
+// logins of the username are forgotten, while earlier failures of the IP are kept, so an attacker
+// can't reset them by logging in to their own account in between guesses.
+func (l *LoginLimiter) Succeed(ctx context.Context, username, ip string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.store.Delete(ctx, usernameKey(username)); err != nil {
+		return err
+	}
+
+	// Only take back the failure counted by the reservation
+	now := l.now()
+	state, err := l.store.Get(ctx, ipKey(ip), now)
+	if err != nil || state.Failures == 0 {
+		return err
+	}
+	state.Failures--
+	if l.ipPolicy.lockout(state.Failures) == 0 {
+		state.LockedUntil = time.Time{}
+	}
+	return l.store.Put(ctx, ipKey(ip), state)
+}
+
+// MemoryAttemptStore is an AttemptStore keeping attempts in memory, for single instance deployments and tests
+type MemoryAttemptStore struct {
+	mu      sync.Mutex
+	entries map[string]AttemptState
+	puts    int
+}
+
+// memoryStoreSweepInterval is how many writes happen between sweeps of expired entries
+const memoryStoreSweepInterval = 1000
+
+// NewMemoryAttemptStore creates an empty MemoryAttemptStore
+func NewMemoryAttemptStore() *MemoryAttemptStore {
+	return &MemoryAttemptStore{entries: make(map[string]AttemptState)}
+}
+
+// Get implements AttemptStore
+func (s *MemoryAttemptStore) Get(_ context.Context, key string, now time.Time) (AttemptState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.entries[key]
+	if !ok || !now.Before(state.ExpiresAt) {
+		return AttemptState{}, nil
+	}
+	return state, nil
+}
+
+// Put implements AttemptStore
+func (s *MemoryAttemptStore) Put(_ context.Context, key string, state AttemptState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = state
+
+	// Sweep expired entries now and then, so attempts with random usernames don't grow the map forever
+	s.puts++
+	if s.puts%memoryStoreSweepInterval == 0 {
+		now := time.Now()
+		for k, v := range s.entries {
+			if !now.Before(v.ExpiresAt) {
+				delete(s.entries, k)
+			}
+		}
+	}
+	return nil
+}
+
+// Delete implements AttemptStore
+func (s *MemoryAttemptStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
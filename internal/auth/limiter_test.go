@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLoginLimiter(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	policy := LockoutPolicy{Threshold: 3, BaseDelay: time.Second, MaxDelay: 4 * time.Second, ResetAfter: time.Hour}
+
+	limiter := NewLoginLimiter(NewMemoryAttemptStore(), policy, LockoutPolicy{Threshold: 10, BaseDelay: time.Second, MaxDelay: time.Minute, ResetAfter: time.Hour},
+		WithClock(func() time.Time { return now }))
+
+	reserve := func(username, ip string) time.Duration {
+		t.Helper()
+		retryAfter, err := limiter.Reserve(ctx, username, ip)
+		if err != nil {
+			t.Fatalf("Reserve() returned error: %v", err)
+		}
+		return retryAfter
+	}
+
+	// Attempts up to the threshold are allowed, and reaching it locks the username out
+	for range 3 {
+		if retryAfter := reserve("alice", "10.0.0.1"); retryAfter != 0 {
+			t.Errorf("expected no lockout below the threshold, got %s", retryAfter)
+		}
+	}
+
+	// The lockout doubles with each failure past the threshold, up to the maximum
+	for _, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+		if retryAfter := reserve("Alice", "10.0.0.2"); retryAfter != want {
+			t.Errorf("expected lockout of %s, got %s", want, retryAfter)
+		}
+		now = now.Add(want)
+		if retryAfter := reserve("alice", "10.0.0.2"); retryAfter != 0 {
+			t.Errorf("expected an attempt once the lockout passed, got %s", retryAfter)
+		}
+	}
+
+	// Other usernames aren't affected until the IP itself is locked out
+	if retryAfter := reserve("bob", "10.0.0.1"); retryAfter != 0 {
+		t.Errorf("expected other usernames to be allowed, got %s", retryAfter)
+	}
+	for i := range 6 {
+		reserve(fmt.Sprintf("unknown%d", i), "10.0.0.1")
+	}
+	if retryAfter := reserve("bob", "10.0.0.1"); retryAfter == 0 {
+		t.Error("expected the IP to be locked out")
+	}
+
+	// A successful login resets the username but not the IP
+	limiter.Succeed(ctx, "alice", "10.0.0.2")
+	if retryAfter := reserve("alice", "10.0.0.2"); retryAfter != 0 {
+		t.Errorf("expected the username to be reset, got %s", retryAfter)
+	}
+	if retryAfter := reserve("alice", "10.0.0.1"); retryAfter == 0 {
+		t.Error("expected the IP lockout to survive a successful login")
+	}
+
+	// Failures are forgotten once they expire
+	now = now.Add(2 * time.Hour)
+	if retryAfter := reserve("bob", "10.0.0.1"); retryAfter != 0 {
+		t.Errorf("expected failures to expire, got %s", retryAfter)
+	}
+}
+
+func TestLoginLimiterSucceedTakesBackReservation(t *testing.T) {
+	ctx := context.Background()
+	policy := LockoutPolicy{Threshold: 3, BaseDelay: time.Minute, MaxDelay: time.Hour, ResetAfter: time.Hour}
+	limiter := NewLoginLimiter(NewMemoryAttemptStore(), policy, policy)
+
+	// Successful logins don't count towards the lockout of their IP
+	for range 5 {
+		if retryAfter, _ := limiter.Reserve(ctx, "alice", "10.0.0.1"); retryAfter != 0 {
+			t.Fatalf("expected successful logins not to lock out the IP, got %s", retryAfter)
+		}
+		limiter.Succeed(ctx, "alice", "10.0.0.1")
+	}
+}
+
+func TestLoginLimiterConcurrentAttempts(t *testing.T) {
+	ctx := context.Background()
+	policy := LockoutPolicy{Threshold: 3, BaseDelay: time.Minute, MaxDelay: time.Hour, ResetAfter: time.Hour}
+	limiter := NewLoginLimiter(NewMemoryAttemptStore(), policy, DefaultIPPolicy)
+
+	// A parallel brute force gets no more attempts than a sequential one
+	var allowed atomic.Int32
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if retryAfter, err := limiter.Reserve(ctx, "alice", "10.0.0.1"); err == nil && retryAfter == 0 {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed.Load() != 3 {
+		t.Errorf("expected 3 concurrent attempts to be allowed, got %d", allowed.Load())
+	}
+}
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"crypto/subtle"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// passwordCost is the bcrypt cost used for new password hashes
+const passwordCost = bcrypt.DefaultCost
+
+// dummyHash is compared against when a user doesn't exist, so that unknown
+// usernames take as long to reject as wrong passwords
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), passwordCost)
+
+// HashPassword returns a salted bcrypt hash of the password
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), passwordCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword reports whether password matches the stored password.
+// Stored passwords that predate hashing are compared in constant time, and
+// needsRehash reports that they, or hashes with an outdated cost, should be
+// replaced with a fresh hash now that the plaintext password is known.
+func CheckPassword(stored, password string) (match bool, needsRehash bool) {
+	cost, err := bcrypt.Cost([]byte(stored))
+	if err != nil {
+		// Legacy plaintext password; an empty one never matches
+		if stored == "" {
+			return false, false
+		}
+		match = subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
+		return match, match
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) != nil {
+		return false, false
+	}
+	return true, cost < passwordCost
+}
+
+// SimulatePasswordCheck spends the time of a password comparison without a stored password
+func SimulatePasswordCheck(password string) {
+	_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
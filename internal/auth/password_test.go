@@ -0,0 +1,36 @@
+package auth
+
+import "testing"
+
+func TestCheckPassword(t *testing.T) {
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatalf("HashPassword() returned error: %v", err)
+	}
+	if hash == "correct horse" {
+		t.Fatal("expected password to be hashed")
+	}
+
+	tests := []struct {
+		name            string
+		stored          string
+		password        string
+		wantMatch       bool
+		wantNeedsRehash bool
+	}{
+		{"hashed match", hash, "correct horse", true, false},
+		{"hashed mismatch", hash, "wrong horse", false, false},
+		{"legacy plaintext match", "correct horse", "correct horse", true, true},
+		{"legacy plaintext mismatch", "correct horse", "wrong horse", false, false},
+		{"empty stored password", "", "", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, needsRehash := CheckPassword(tt.stored, tt.password)
+			if match != tt.wantMatch || needsRehash != tt.wantNeedsRehash {
+				t.Errorf("CheckPassword() = (%v, %v), want (%v, %v)", match, needsRehash, tt.wantMatch, tt.wantNeedsRehash)
+			}
+		})
+	}
+}
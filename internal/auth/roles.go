@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Roles users can be granted. Users are viewers by default.
+const (
+	// RoleViewer can read stored data and their own history
+	RoleViewer = "viewer"
+	// RoleFetcher can add shared data, such as contract ABIs
+	RoleFetcher = "fetcher"
+	// RoleSigner can send transactions signed with the server's key, spending its gas
+	RoleSigner = "signer"
+	// RoleAdmin implies every other role and can grant and revoke roles
+	RoleAdmin = "admin"
+)
+
+// Roles lists all known roles
+var Roles = []string{RoleViewer, RoleFetcher, RoleSigner, RoleAdmin}
+
+// DefaultRoles are granted to newly registered users
+var DefaultRoles = []string{RoleViewer}
+
+// HasRole reports whether the roles include the required role, which admins always do
+func HasRole(roles []string, required string) bool {
+	return slices.Contains(roles, required) || slices.Contains(roles, RoleAdmin)
+}
+
+// ValidateRole checks that the role is known
+func ValidateRole(role string) error {
+	if !slices.Contains(Roles, role) {
+		return fmt.Errorf("unknown role %q", role)
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package auth
+
+import "testing"
+
+func TestHasRole(t *testing.T) {
+	tests := []struct {
+		name     string
+		roles    []string
+		required string
+		want     bool
+	}{
+		{"granted role", []string{RoleViewer, RoleSigner}, RoleSigner, true},
+		{"missing role", []string{RoleViewer}, RoleSigner, false},
+		{"admin implies all", []string{RoleAdmin}, RoleSigner, true},
+		{"no roles", nil, RoleViewer, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasRole(tt.roles, tt.required); got != tt.want {
+				t.Errorf("HasRole(%v, %q) = %v, want %v", tt.roles, tt.required, got, tt.want)
+			}
+		})
+	}
+}
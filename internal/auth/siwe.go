@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ErrInvalidSIWEMessage is returned for Sign-In with Ethereum messages that are malformed,
+// not meant for this service, outside of their validity period or not signed by their address
+var ErrInvalidSIWEMessage = errors.New("invalid Sign-In with Ethereum message")
+
+const siweHeaderSuffix = " wants you to sign in with your Ethereum account:"
+
+// SIWEMessage is a parsed EIP-4361 Sign-In with Ethereum message
+type SIWEMessage struct {
+	Domain         string
+	Address        common.Address
+	Statement      string
+	URI            string
+	Version        string
+	ChainID        int64
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime *time.Time
+	NotBefore      *time.Time
+	RequestID      string
+	Resources      []string
+}
+
+// NewSIWENonce returns a random nonce for a Sign-In with Ethereum message
+func NewSIWENonce() (string, error) {
+	b, err := randomBytes(16)
+	if err != nil {
+		return "", err
+	}
+	// EIP-4361 nonces must be alphanumeric
+	return strings.TrimPrefix(hexutil.Encode(b), "0x"), nil
+}
+
+// ParseSIWEMessage parses an EIP-4361 message
+func ParseSIWEMessage(message string) (*SIWEMessage, error) {
+	lines := strings.Split(strings.ReplaceAll(message, "\r\n", "\n"), "\n")
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s", ErrInvalidSIWEMessage, fmt.Sprintf(format, args...))
+	}
+
+	if len(lines) < 2 || !strings.HasSuffix(lines[0], siweHeaderSuffix) {
+		return nil, invalid("missing header")
+	}
+	parsed := &SIWEMessage{Domain: strings.TrimSuffix(lines[0], siweHeaderSuffix)}
+
+	// Addresses must be EIP-55 checksummed, so a mistyped address isn't silently accepted
+	if !common.IsHexAddress(lines[1]) || common.HexToAddress(lines[1]).Hex() != lines[1] {
+		return nil, invalid("address %q is not checksummed", lines[1])
+	}
+	parsed.Address = common.HexToAddress(lines[1])
+
+	// The optional statement is surrounded by blank lines, before the fields
+	i := 2
+	for i < len(lines) && lines[i] == "" {
+		i++
+	}
+	if i < len(lines) && !strings.HasPrefix(lines[i], "URI: ") {
+		parsed.Statement = lines[i]
+		i++
+		for i < len(lines) && lines[i] == "" {
+			i++
+		}
+	}
+
+	fields := make(map[string]string)
+	for ; i < len(lines); i++ {
+		if lines[i] == "Resources:" {
+			for _, resource := range lines[i+1:] {
+				if resource == "" {
+					continue
+				}
+				if !strings.HasPrefix(resource, "- ") {
+					return nil, invalid("malformed resource %q", resource)
+				}
+				parsed.Resources = append(parsed.Resources, strings.TrimPrefix(resource, "- "))
+			}
+			break
+		}
+		if lines[i] == "" {
+			continue
+		}
+
+		key, value, found := strings.Cut(lines[i], ": ")
+		if !found {
+			return nil, invalid("malformed line %q", lines[i])
+		}
+		if _, duplicate := fields[key]; duplicate {
+			return nil, invalid("duplicate field %q", key)
+		}
+		fields[key] = value
+	}
+
+	for _, required := range []string{"URI", "Version", "Chain ID", "Nonce", "Issued At"} {
+		if fields[required] == "" {
+			return nil, invalid("missing %s", required)
+		}
+	}
+
+	parsed.URI = fields["URI"]
+	parsed.Version = fields["Version"]
+	parsed.Nonce = fields["Nonce"]
+	parsed.RequestID = fields["Request ID"]
+
+	chainID, err := strconv.ParseInt(fields["Chain ID"], 10, 64)
+	if err != nil {
+		return nil, invalid("malformed Chain ID")
+	}
+	parsed.ChainID = chainID
+
+	if parsed.IssuedAt, err = time.Parse(time.RFC3339, fields["Issued At"]); err != nil {
+		return nil, invalid("malformed Issued At")
+	}
+	if value := fields["Expiration Time"]; value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, invalid("malformed Expiration Time")
+		}
+		parsed.ExpirationTime = &t
+	}
+	if value := fields["Not Before"]; value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, invalid("malformed Not Before")
+		}
+		parsed.NotBefore = &t
+	}
+
+	return parsed, nil
+}
+
+// Validate checks that the message is meant for the domain and chain and valid at the given time.
+// The URI must be served from the domain, so messages signed for another site are rejected.
+// The nonce must be checked separately.
+func (m *SIWEMessage) Validate(domain string, chainID int64, now time.Time) error {
+	if m.Version != "1" {
+		return fmt.Errorf("%w: unsupported version %q", ErrInvalidSIWEMessage, m.Version)
+	}
+	if m.Domain != domain {
+		return fmt.Errorf("%w: unexpected domain %q", ErrInvalidSIWEMessage, m.Domain)
+	}
+	if uri, err := url.Parse(m.URI); err != nil || (uri.Scheme != "https" && uri.Scheme != "http") || uri.Host != domain {
+		return fmt.Errorf("%w: unexpected URI %q", ErrInvalidSIWEMessage, m.URI)
+	}
+	if m.ChainID != chainID {
+		return fmt.Errorf("%w: unexpected chain ID %d", ErrInvalidSIWEMessage, m.ChainID)
+	}
+	if m.ExpirationTime != nil && !now.Before(*m.ExpirationTime) {
+		return fmt.Errorf("%w: expired", ErrInvalidSIWEMessage)
+	}
+	if m.NotBefore != nil && now.Before(*m.NotBefore) {
+		return fmt.Errorf("%w: not yet valid", ErrInvalidSIWEMessage)
+	}
+	return nil
+}
+
+// VerifySIWESignature checks that the message was signed with personal_sign by the given address.
+// Signatures of contract wallets (EIP-1271) are not supported.
+func VerifySIWESignature(message string, signature string, address common.Address) error {
+	sig, err := hexutil.Decode(signature)
+	if err != nil || len(sig) != crypto.SignatureLength {
+		return fmt.Errorf("%w: malformed signature", ErrInvalidSIWEMessage)
+	}
+
+	// Wallets return the recovery ID as 27 or 28, while go-ethereum expects 0 or 1
+	sig = append([]byte(nil), sig...)
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+
+	publicKey, err := crypto.SigToPub(accounts.TextHash([]byte(message)), sig)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSIWEMessage, err)
+	}
+	if crypto.PubkeyToAddress(*publicKey) != address {
+		return fmt.Errorf("%w: signature does not match address", ErrInvalidSIWEMessage)
+	}
+	return nil
+}
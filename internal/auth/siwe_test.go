@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestSIWE(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := crypto.PubkeyToAddress(key.PublicKey)
+
+	issuedAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	message := fmt.Sprintf(`example.com wants you to sign in with your Ethereum account:
+%s
+
+Sign in to the fetcher.
+
+URI: https://example.com/login
+Version: 1
+Chain ID: 11155111
+Nonce: 32891756a1b2c3d4
+Issued At: %s
+Expiration Time: %s
+Resources:
+- https://example.com/terms`, address.Hex(), issuedAt.Format(time.RFC3339), issuedAt.Add(10*time.Minute).Format(time.RFC3339))
+
+	sig, err := crypto.Sign(accounts.TextHash([]byte(message)), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+	signature := hexutil.Encode(sig)
+
+	parsed, err := ParseSIWEMessage(message)
+	if err != nil {
+		t.Fatalf("ParseSIWEMessage() returned error: %v", err)
+	}
+	if parsed.Domain != "example.com" || parsed.Address != address || parsed.Statement != "Sign in to the fetcher." ||
+		parsed.ChainID != 11155111 || parsed.Nonce != "32891756a1b2c3d4" || !parsed.IssuedAt.Equal(issuedAt) ||
+		len(parsed.Resources) != 1 {
+		t.Errorf("unexpected parsed message: %+v", parsed)
+	}
+
+	if err := parsed.Validate("example.com", 11155111, issuedAt.Add(time.Minute)); err != nil {
+		t.Errorf("Validate() returned error: %v", err)
+	}
+	if err := VerifySIWESignature(message, signature, address); err != nil {
+		t.Errorf("VerifySIWESignature() returned error: %v", err)
+	}
+
+	unchecksummed, _ := ParseSIWEMessage(strings.Replace(message, address.Hex(), strings.ToLower(address.Hex()), 1))
+	otherKey, _ := crypto.GenerateKey()
+	otherSite, _ := ParseSIWEMessage(strings.Replace(message, "URI: https://example.com/login", "URI: https://evil.com/login", 1))
+
+	invalid := []struct {
+		name string
+		err  error
+	}{
+		{"wrong domain", parsed.Validate("evil.com", 11155111, issuedAt.Add(time.Minute))},
+		{"wrong chain", parsed.Validate("example.com", 1, issuedAt.Add(time.Minute))},
+		{"other site URI", otherSite.Validate("example.com", 11155111, issuedAt.Add(time.Minute))},
+		{"expired", parsed.Validate("example.com", 11155111, issuedAt.Add(time.Hour))},
+		{"tampered message", VerifySIWESignature(message+" ", signature, address)},
+		{"other address", VerifySIWESignature(message, signature, crypto.PubkeyToAddress(otherKey.PublicKey))},
+		{"malformed signature", VerifySIWESignature(message, "0x1234", address)},
+	}
+	for _, tt := range invalid {
+		if !errors.Is(tt.err, ErrInvalidSIWEMessage) {
+			t.Errorf("%s: expected %v, got %v", tt.name, ErrInvalidSIWEMessage, tt.err)
+		}
+	}
+	withoutStatement, err := ParseSIWEMessage(strings.Replace(message, "\nSign in to the fetcher.\n", "", 1))
+	if err != nil || withoutStatement.Statement != "" || withoutStatement.URI != parsed.URI {
+		t.Errorf("expected message without statement to parse, got %+v (%v)", withoutStatement, err)
+	}
+	if unchecksummed != nil {
+		t.Error("expected a message with an unchecksummed address to be rejected")
+	}
+}
@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// ErrInvalidToken is returned for tokens that are malformed, expired or not issued for this service
+var ErrInvalidToken = errors.New("invalid token")
+
+// Claims are the claims carried by an access token
+type Claims struct {
+	Username string `json:"username"`
+	jwt.RegisteredClaims
+}
+
+// UserID returns the ID of the user the token was issued to
+func (c *Claims) UserID() (int, error) {
+	id, err := strconv.Atoi(c.Subject)
+	if err != nil {
+		return 0, fmt.Errorf("%w: malformed subject", ErrInvalidToken)
+	}
+	return id, nil
+}
+
+// TokenManager issues and validates signed access tokens and generates refresh tokens
+type TokenManager struct {
+	keys       *KeySet
+	issuer     string
+	audience   string
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+// NewTokenManager creates a TokenManager signing tokens with the active key of the set
+func NewTokenManager(keys *KeySet, issuer, audience string, accessTTL, refreshTTL time.Duration) (*TokenManager, error) {
+	if accessTTL <= 0 || refreshTTL <= 0 {
+		return nil, errors.New("token lifetimes must be positive")
+	}
+
+	return &TokenManager{
+		keys:       keys,
+		issuer:     issuer,
+		audience:   audience,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+	}, nil
+}
+
+// JWKS returns the public keys tokens can be verified with
+func (m *TokenManager) JWKS() JWKS {
+	return m.keys.JWKS()
+}
+
+// AccessTTL returns how long issued access tokens are valid
+func (m *TokenManager) AccessTTL() time.Duration {
+	return m.accessTTL
+}
+
+// Issue returns a signed access token for the user
+func (m *TokenManager) Issue(userID int, username string) (string, error) {
+	id, err := randomToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	return m.keys.sign(Claims{
+		Username: username,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        id,
+			Subject:   strconv.Itoa(userID),
+			Issuer:    m.issuer,
+			Audience:  jwt.ClaimStrings{m.audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(m.accessTTL)),
+		},
+	})
+}
+
+// Parse validates the signature, key, algorithm, expiry, issuer and audience of a token and returns its claims
+func (m *TokenManager) Parse(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, m.keys.verifyKey, jwt.WithValidMethods([]string{
+		jwt.SigningMethodHS256.Alg(),
+		jwt.SigningMethodRS256.Alg(),
+		jwt.SigningMethodES256.Alg(),
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	// The parser accepts tokens without an expiry, but access tokens must always expire
+	if claims.ExpiresAt == nil {
+		return nil, fmt.Errorf("%w: missing expiry", ErrInvalidToken)
+	}
+	// The token ID is what revocation is keyed on
+	if claims.ID == "" {
+		return nil, fmt.Errorf("%w: missing token ID", ErrInvalidToken)
+	}
+	if !claims.VerifyIssuer(m.issuer, true) || !claims.VerifyAudience(m.audience, true) {
+		return nil, fmt.Errorf("%w: unexpected issuer or audience", ErrInvalidToken)
+	}
+
+	return claims, nil
+}
+
+// NewRefreshToken returns a random refresh token, the hash to store in its place and its expiry
+func (m *TokenManager) NewRefreshToken() (token, hash string, expiresAt time.Time, err error) {
+	token, err = randomToken(32)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	return token, HashToken(token), time.Now().Add(m.refreshTTL), nil
+}
+
+// HashToken returns the hash under which a refresh token or API key is stored.
+// Both are random and long, so a fast unsalted hash is sufficient.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomToken returns n random bytes encoded as URL-safe base64
+func randomToken(n int) (string, error) {
+	b, err := randomBytes(n)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// randomBytes returns n cryptographically secure random bytes
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	return b, nil
+}
@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func newTestTokenManager(t *testing.T) *TokenManager {
+	t.Helper()
+
+	keys, err := NewHMACKeySet("secret")
+	if err != nil {
+		t.Fatalf("NewHMACKeySet() returned error: %v", err)
+	}
+	manager, err := NewTokenManager(keys, "issuer", "audience", time.Minute, time.Hour)
+	if err != nil {
+		t.Fatalf("NewTokenManager() returned error: %v", err)
+	}
+	return manager
+}
+
+func TestTokenManager(t *testing.T) {
+	manager := newTestTokenManager(t)
+
+	token, err := manager.Issue(42, "alice")
+	if err != nil {
+		t.Fatalf("Issue() returned error: %v", err)
+	}
+
+	claims, err := manager.Parse(token)
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+	if id, err := claims.UserID(); err != nil || id != 42 || claims.Username != "alice" || claims.ID == "" {
+		t.Errorf("unexpected claims: user %d (%v), username %q, ID %q", id, err, claims.Username, claims.ID)
+	}
+
+	now := time.Now()
+	valid := jwt.RegisteredClaims{
+		ID:        "token-id",
+		Subject:   "42",
+		Issuer:    "issuer",
+		Audience:  jwt.ClaimStrings{"audience"},
+		ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+	}
+
+	tests := []struct {
+		name   string
+		method jwt.SigningMethod
+		key    any
+		claims jwt.RegisteredClaims
+	}{
+		{"wrong secret", jwt.SigningMethodHS256, []byte("other"), valid},
+		{"unexpected algorithm", jwt.SigningMethodHS512, []byte("secret"), valid},
+		{"unsigned", jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, valid},
+		{"expired", jwt.SigningMethodHS256, []byte("secret"), func() jwt.RegisteredClaims {
+			c := valid
+			c.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Minute))
+			return c
+		}()},
+		{"missing expiry", jwt.SigningMethodHS256, []byte("secret"), func() jwt.RegisteredClaims {
+			c := valid
+			c.ExpiresAt = nil
+			return c
+		}()},
+		{"missing token ID", jwt.SigningMethodHS256, []byte("secret"), func() jwt.RegisteredClaims {
+			c := valid
+			c.ID = ""
+			return c
+		}()},
+		{"wrong issuer", jwt.SigningMethodHS256, []byte("secret"), func() jwt.RegisteredClaims {
+			c := valid
+			c.Issuer = "other"
+			return c
+		}()},
+		{"wrong audience", jwt.SigningMethodHS256, []byte("secret"), func() jwt.RegisteredClaims {
+			c := valid
+			c.Audience = jwt.ClaimStrings{"other"}
+			return c
+		}()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := jwt.NewWithClaims(tt.method, Claims{RegisteredClaims: tt.claims}).SignedString(tt.key)
+			if err != nil {
+				t.Fatalf("failed to sign token: %v", err)
+			}
+			if _, err := manager.Parse(token); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Parse() error = %v, want %v", err, ErrInvalidToken)
+			}
+		})
+	}
+}
+
+func TestNewRefreshToken(t *testing.T) {
+	manager := newTestTokenManager(t)
+
+	token, hash, expiresAt, err := manager.NewRefreshToken()
+	if err != nil {
+		t.Fatalf("NewRefreshToken() returned error: %v", err)
+	}
+	if token == "" || hash == token || hash != HashToken(token) {
+		t.Errorf("expected the hash of the token to be stored, got token %q hash %q", token, hash)
+	}
+	if time.Until(expiresAt) <= 59*time.Minute {
+		t.Errorf("expected refresh token to expire in an hour, got %s", expiresAt)
+	}
+
+	other, _, _, _ := manager.NewRefreshToken()
+	if other == token {
+		t.Error("expected refresh tokens to be unique")
+	}
+}
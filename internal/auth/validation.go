@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	minUsernameLength = 3
+	maxUsernameLength = 32
+
+	minPasswordLength = 10
+	// maxPasswordLength is the most bcrypt hashes, longer passwords are rejected by it
+	maxPasswordLength = 72
+)
+
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+// ValidateUsername checks the length and charset of a username chosen at registration
+func ValidateUsername(username string) error {
+	if len(username) < minUsernameLength || len(username) > maxUsernameLength {
+		return fmt.Errorf("username must be between %d and %d characters", minUsernameLength, maxUsernameLength)
+	}
+	if !usernamePattern.MatchString(username) {
+		return errors.New("username may only contain letters, digits, '_', '.' and '-'")
+	}
+	// Addresses are the usernames of accounts created by Sign-In with Ethereum
+	if common.IsHexAddress(username) {
+		return errors.New("username must not be an Ethereum address")
+	}
+	return nil
+}
+
+// ValidatePassword checks that a new password is long enough, mixes character classes
+// and doesn't contain the username
+func ValidatePassword(password, username string) error {
+	if len(password) < minPasswordLength || len(password) > maxPasswordLength {
+		return fmt.Errorf("password must be between %d and %d bytes", minPasswordLength, maxPasswordLength)
+	}
+
+	var letter, digit, other bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			letter = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+	if !letter || !(digit || other) {
+		return errors.New("password must contain letters and digits or symbols")
+	}
+
+	if username != "" && strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		return errors.New("password must not contain the username")
+	}
+	return nil
+}
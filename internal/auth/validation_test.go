@@ -0,0 +1,43 @@
+package auth
+
+import "testing"
+
+func TestValidateUsername(t *testing.T) {
+	tests := []struct {
+		username string
+		valid    bool
+	}{
+		{"alice", true},
+		{"alice.smith-2_x", true},
+		{"al", false},
+		{"alice smith", false},
+		{"<script>", false},
+		{"0x5B38Da6a701c568545dCfcB03FcB875f56beddC4", false},
+	}
+
+	for _, tt := range tests {
+		if err := ValidateUsername(tt.username); (err == nil) != tt.valid {
+			t.Errorf("ValidateUsername(%q) = %v, want valid %v", tt.username, err, tt.valid)
+		}
+	}
+}
+
+func TestValidatePassword(t *testing.T) {
+	tests := []struct {
+		password string
+		valid    bool
+	}{
+		{"correct horse battery", true},
+		{"tr0ub4dor3x", true},
+		{"short1", false},
+		{"onlyletterspassword", false},
+		{"1234567890123", false},
+		{"alice-is-the-best1", false},
+	}
+
+	for _, tt := range tests {
+		if err := ValidatePassword(tt.password, "Alice"); (err == nil) != tt.valid {
+			t.Errorf("ValidatePassword(%q) = %v, want valid %v", tt.password, err, tt.valid)
+		}
+	}
+}
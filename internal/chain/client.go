@@ -0,0 +1,270 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// healthCheckInterval is how often the client checks that the endpoints are reachable
+const healthCheckInterval = 30 * time.Second
+
+// ErrNoEndpoints is returned when no endpoint is configured
+var ErrNoEndpoints = errors.New("no Ethereum RPC endpoints configured")
+
+// Backend is the subset of the Ethereum JSON-RPC API used by the server
+type Backend interface {
+	bind.ContractBackend
+
+	ChainID(ctx context.Context) (*big.Int, error)
+	BlockNumber(ctx context.Context) (uint64, error)
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+	TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error)
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error)
+}
+
+// Service represents a long-lived Ethereum node connection
+type Service interface {
+	Backend
+
+	// Health returns a map of health status information,
+	// including per-endpoint statistics.
+	Health() map[string]any
+
+	// Close stops the health checks and closes the connections.
+	Close()
+}
+
+// Client is a Service backed by one or more JSON-RPC endpoints. Requests go to the
+// preferred available endpoint and fail over to the next one on transport errors
+// or rate limiting. Endpoints are health checked and reconnected in the background.
+type Client struct {
+	endpoints []*endpoint
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Dial connects to the given endpoints and starts the background health checks
+func Dial(configs []Endpoint) (*Client, error) {
+	if len(configs) == 0 {
+		return nil, ErrNoEndpoints
+	}
+
+	c := &Client{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	for _, config := range configs {
+		ep, err := newEndpoint(config)
+		if err != nil {
+			c.closeEndpoints()
+			return nil, err
+		}
+		c.endpoints = append(c.endpoints, ep)
+	}
+	go c.run()
+
+	return c, nil
+}
+
+// run periodically checks the endpoints until the client is closed
+func (c *Client) run() {
+	defer close(c.done)
+
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			for _, ep := range c.endpoints {
+				ep.check()
+			}
+		}
+	}
+}
+
+// call runs fn against the available endpoints in order of preference until one
+// succeeds or fails with an error that another endpoint would not fix
+func call[T any](ctx context.Context, c *Client, fn func(*ethclient.Client) (T, error)) (T, error) {
+	var (
+		zero    T
+		lastErr error
+	)
+
+	for _, ep := range c.candidates() {
+		conn := ep.conn()
+		if conn == nil {
+			lastErr = fmt.Errorf("%s: not connected", ep.name)
+			continue
+		}
+
+		start := time.Now()
+		res, err := fn(conn)
+		if err != nil && ctx.Err() != nil {
+			// The caller gave up or ran out of time, which says nothing about the endpoint
+			return zero, err
+		}
+		ep.record(time.Since(start), err)
+
+		if !isFailoverError(err) {
+			return res, err
+		}
+		lastErr = fmt.Errorf("%s: %w", ep.name, err)
+	}
+
+	if lastErr == nil {
+		lastErr = ErrNoEndpoints
+	}
+	return zero, lastErr
+}
+
+// Health returns the overall status and the statistics of every endpoint
+func (c *Client) Health() map[string]any {
+	stats := make([]EndpointStats, len(c.endpoints))
+	available := 0
+	for i, ep := range c.endpoints {
+		stats[i] = ep.stats()
+		if stats[i].State == stateClosed && stats[i].LastError == "" {
+			available++
+		}
+	}
+
+	status := "up"
+	switch {
+	case available == 0:
+		status = "down"
+	case available < len(c.endpoints):
+		status = "degraded"
+	}
+
+	return map[string]any{
+		"status":    status,
+		"endpoints": stats,
+	}
+}
+
+// Close stops the health checks and closes the connections
+func (c *Client) Close() {
+	close(c.stop)
+	<-c.done
+	c.closeEndpoints()
+	log.Printf("Disconnected from Ethereum nodes")
+}
+
+func (c *Client) closeEndpoints() {
+	for _, ep := range c.endpoints {
+		if conn := ep.conn(); conn != nil {
+			conn.Close()
+		}
+	}
+}
+
+// ChainID retrieves the chain ID of the connected node
+func (c *Client) ChainID(ctx context.Context) (*big.Int, error) {
+	return call(ctx, c, func(ec *ethclient.Client) (*big.Int, error) { return ec.ChainID(ctx) })
+}
+
+// BlockNumber returns the most recent block number
+func (c *Client) BlockNumber(ctx context.Context) (uint64, error) {
+	return call(ctx, c, func(ec *ethclient.Client) (uint64, error) { return ec.BlockNumber(ctx) })
+}
+
+// NonceAt returns the account nonce of the given account at the given block
+func (c *Client) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	return call(ctx, c, func(ec *ethclient.Client) (uint64, error) { return ec.NonceAt(ctx, account, blockNumber) })
+}
+
+// TransactionByHash returns the transaction with the given hash
+func (c *Client) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	var isPending bool
+	tx, err := call(ctx, c, func(ec *ethclient.Client) (*types.Transaction, error) {
+		tx, pending, err := ec.TransactionByHash(ctx, hash)
+		isPending = pending
+		return tx, err
+	})
+	return tx, isPending, err
+}
+
+// TransactionReceipt returns the receipt of a mined transaction
+func (c *Client) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	return call(ctx, c, func(ec *ethclient.Client) (*types.Receipt, error) { return ec.TransactionReceipt(ctx, txHash) })
+}
+
+// CodeAt returns the contract code of the given account
+func (c *Client) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	return call(ctx, c, func(ec *ethclient.Client) ([]byte, error) { return ec.CodeAt(ctx, account, blockNumber) })
+}
+
+// CallContract executes a message call transaction
+func (c *Client) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return call(ctx, c, func(ec *ethclient.Client) ([]byte, error) { return ec.CallContract(ctx, msg, blockNumber) })
+}
+
+// HeaderByNumber returns a block header from the current canonical chain
+func (c *Client) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return call(ctx, c, func(ec *ethclient.Client) (*types.Header, error) { return ec.HeaderByNumber(ctx, number) })
+}
+
+// PendingCodeAt returns the contract code of the given account in the pending state
+func (c *Client) PendingCodeAt(ctx context.Context, account common.Address) ([]byte, error) {
+	return call(ctx, c, func(ec *ethclient.Client) ([]byte, error) { return ec.PendingCodeAt(ctx, account) })
+}
+
+// PendingNonceAt returns the account nonce of the given account in the pending state
+func (c *Client) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return call(ctx, c, func(ec *ethclient.Client) (uint64, error) { return ec.PendingNonceAt(ctx, account) })
+}
+
+// SuggestGasPrice retrieves the currently suggested gas price
+func (c *Client) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return call(ctx, c, func(ec *ethclient.Client) (*big.Int, error) { return ec.SuggestGasPrice(ctx) })
+}
+
+// SuggestGasTipCap retrieves the currently suggested gas tip cap
+func (c *Client) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return call(ctx, c, func(ec *ethclient.Client) (*big.Int, error) { return ec.SuggestGasTipCap(ctx) })
+}
+
+// FeeHistory returns the base fees and priority fee percentiles of recent blocks
+func (c *Client) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error) {
+	return call(ctx, c, func(ec *ethclient.Client) (*ethereum.FeeHistory, error) {
+		return ec.FeeHistory(ctx, blockCount, lastBlock, rewardPercentiles)
+	})
+}
+
+// EstimateGas estimates the gas needed to execute a transaction
+func (c *Client) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	return call(ctx, c, func(ec *ethclient.Client) (uint64, error) { return ec.EstimateGas(ctx, msg) })
+}
+
+// SendTransaction injects a signed transaction into the pending pool.
+// Resending the same signed transaction to another endpoint is safe, as it keeps its hash.
+func (c *Client) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	_, err := call(ctx, c, func(ec *ethclient.Client) (struct{}, error) { return struct{}{}, ec.SendTransaction(ctx, tx) })
+	return err
+}
+
+// FilterLogs executes a filter query
+func (c *Client) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	return call(ctx, c, func(ec *ethclient.Client) ([]types.Log, error) { return ec.FilterLogs(ctx, q) })
+}
+
+// SubscribeFilterLogs subscribes to the results of a streaming filter query
+func (c *Client) SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return call(ctx, c, func(ec *ethclient.Client) (ethereum.Subscription, error) { return ec.SubscribeFilterLogs(ctx, q, ch) })
+}
@@ -0,0 +1,181 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newRPCServer starts a JSON-RPC server that answers eth_blockNumber with the given
+// block number, or with the given HTTP status when it is not zero.
+func newRPCServer(t *testing.T, status int, blockNumber string) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status != 0 {
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+
+		var req struct {
+			ID json.RawMessage `json:"id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": blockNumber})
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestParseEndpoints(t *testing.T) {
+	endpoints, err := ParseEndpoints("http://a.example, http://b.example|1|3")
+	if err != nil {
+		t.Fatalf("ParseEndpoints() returned error: %v", err)
+	}
+
+	want := []Endpoint{
+		{URL: "http://a.example", Priority: 0, Weight: 1},
+		{URL: "http://b.example", Priority: 1, Weight: 3},
+	}
+	if len(endpoints) != len(want) {
+		t.Fatalf("expected %d endpoints, got %d", len(want), len(endpoints))
+	}
+	for i := range want {
+		if endpoints[i] != want[i] {
+			t.Errorf("endpoint %d: expected %+v, got %+v", i, want[i], endpoints[i])
+		}
+	}
+
+	if _, err := ParseEndpoints("http://a.example|1|0"); err == nil {
+		t.Errorf("expected error for a zero weight")
+	}
+}
+
+func TestClientFailover(t *testing.T) {
+	limited := newRPCServer(t, http.StatusTooManyRequests, "")
+	healthy := newRPCServer(t, 0, "0x10")
+
+	client, err := Dial([]Endpoint{
+		{URL: limited.URL, Priority: 0, Weight: 1},
+		{URL: healthy.URL, Priority: 1, Weight: 1},
+	})
+	if err != nil {
+		t.Fatalf("Dial() returned error: %v", err)
+	}
+	defer client.Close()
+
+	for i := 0; i < breakerThreshold; i++ {
+		blockNumber, err := client.BlockNumber(context.Background())
+		if err != nil {
+			t.Fatalf("expected failover to the healthy endpoint, got error: %v", err)
+		}
+		if blockNumber != 16 {
+			t.Fatalf("expected block number 16, got %d", blockNumber)
+		}
+	}
+
+	stats := client.Health()["endpoints"].([]EndpointStats)
+	if stats[0].State != stateOpen {
+		t.Errorf("expected circuit of the rate-limited endpoint to be open, got %s", stats[0].State)
+	}
+	if stats[0].Failures != breakerThreshold {
+		t.Errorf("expected %d failures, got %d", breakerThreshold, stats[0].Failures)
+	}
+	if stats[1].Requests != breakerThreshold || stats[1].Failures != 0 {
+		t.Errorf("expected %d successful requests on the healthy endpoint, got %+v", breakerThreshold, stats[1])
+	}
+
+	// Once the circuit is open, requests go straight to the healthy endpoint
+	if _, err := client.BlockNumber(context.Background()); err != nil {
+		t.Fatalf("BlockNumber() returned error: %v", err)
+	}
+	if stats := client.Health()["endpoints"].([]EndpointStats); stats[0].Requests != breakerThreshold {
+		t.Errorf("expected the open endpoint to be skipped, got %d requests", stats[0].Requests)
+	}
+}
+
+func TestClientCallerTimeout(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	t.Cleanup(slow.Close)
+
+	client, err := Dial([]Endpoint{{URL: slow.URL, Weight: 1}})
+	if err != nil {
+		t.Fatalf("Dial() returned error: %v", err)
+	}
+	defer client.Close()
+
+	// Requests timing out on the caller's side don't open the circuit of a healthy endpoint
+	for range breakerThreshold + 1 {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		if _, err := client.BlockNumber(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected context.DeadlineExceeded, got %v", err)
+		}
+		cancel()
+	}
+
+	stats := client.Health()["endpoints"].([]EndpointStats)
+	if stats[0].State != stateClosed || stats[0].Failures != 0 {
+		t.Errorf("expected the endpoint to stay closed without failures, got %+v", stats[0])
+	}
+}
+
+func TestClientUnreachableEndpoint(t *testing.T) {
+	healthy := newRPCServer(t, 0, "0x10")
+
+	// Nothing listens on port 1, so the websocket connection is refused
+	client, err := Dial([]Endpoint{
+		{URL: "ws://127.0.0.1:1", Priority: 0, Weight: 1},
+		{URL: healthy.URL, Priority: 1, Weight: 1},
+	})
+	if err != nil {
+		t.Fatalf("expected an unreachable endpoint not to fail Dial(), got %v", err)
+	}
+	defer client.Close()
+
+	if blockNumber, err := client.BlockNumber(context.Background()); err != nil || blockNumber != 16 {
+		t.Fatalf("expected block number 16 from the healthy endpoint, got %d, %v", blockNumber, err)
+	}
+
+	stats := client.Health()["endpoints"].([]EndpointStats)
+	if stats[0].State != stateOpen || stats[0].LastError == "" {
+		t.Errorf("expected the unreachable endpoint to start open, got %+v", stats[0])
+	}
+	if client.Health()["status"] != "degraded" {
+		t.Errorf("expected status degraded, got %v", client.Health()["status"])
+	}
+}
+
+func TestEndpointConnectsOnHealthCheck(t *testing.T) {
+	healthy := newRPCServer(t, 0, "0x10")
+
+	// The endpoint was unreachable at startup, and the health check connects it
+	ep := &endpoint{Endpoint: Endpoint{URL: healthy.URL, Weight: 1}, name: "healthy"}
+	ep.check()
+
+	client := ep.conn()
+	if client == nil {
+		t.Fatal("expected the health check to connect the endpoint")
+	}
+	defer client.Close()
+
+	// Later checks reuse the connection rather than replacing it under in-flight requests
+	ep.check()
+	if ep.conn() != client {
+		t.Error("expected the connection to be kept")
+	}
+}
@@ -0,0 +1,327 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// breakerThreshold is the number of consecutive failures that opens an endpoint's circuit
+	breakerThreshold = 3
+	// breakerCooldown is how long an open circuit rejects requests before it is tried again
+	breakerCooldown = 30 * time.Second
+
+	// latencySmoothing is the weight of the latest sample in the average latency
+	latencySmoothing = 0.2
+
+	stateClosed = "closed"
+	stateOpen   = "open"
+)
+
+// Endpoint configures a single JSON-RPC endpoint
+type Endpoint struct {
+	URL string
+	// Priority orders endpoints; lower values are preferred
+	Priority int
+	// Weight balances the load between endpoints of the same priority
+	Weight int
+}
+
+// ParseEndpoints parses a comma-separated list of endpoints in the form
+// "url|priority|weight", where priority and weight are optional.
+func ParseEndpoints(value string) ([]Endpoint, error) {
+	var endpoints []Endpoint
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, "|")
+		if len(parts) > 3 {
+			return nil, fmt.Errorf("invalid endpoint %q: expected url|priority|weight", entry)
+		}
+
+		endpoint := Endpoint{URL: parts[0], Weight: 1}
+		if len(parts) > 1 {
+			priority, err := strconv.Atoi(parts[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid priority for endpoint %q: %w", parts[0], err)
+			}
+			endpoint.Priority = priority
+		}
+		if len(parts) > 2 {
+			weight, err := strconv.Atoi(parts[2])
+			if err != nil || weight <= 0 {
+				return nil, fmt.Errorf("invalid weight for endpoint %q: must be a positive integer", parts[0])
+			}
+			endpoint.Weight = weight
+		}
+
+		endpoints = append(endpoints, endpoint)
+	}
+
+	return endpoints, nil
+}
+
+// EndpointStats describes the state of a single endpoint
+type EndpointStats struct {
+	Name                string `json:"name"`
+	Priority            int    `json:"priority"`
+	Weight              int    `json:"weight"`
+	State               string `json:"state"`
+	Requests            int64  `json:"requests"`
+	Failures            int64  `json:"failures"`
+	ConsecutiveFailures int    `json:"consecutive_failures"`
+	AvgLatency          string `json:"avg_latency"`
+	LastLatency         string `json:"last_latency"`
+	LastError           string `json:"last_error,omitempty"`
+	LastCheck           string `json:"last_check,omitempty"`
+	BlockNumber         uint64 `json:"block_number,omitempty"`
+}
+
+// endpoint is a connection to a single JSON-RPC endpoint with its circuit breaker and statistics
+type endpoint struct {
+	Endpoint
+	// name identifies the endpoint without leaking credentials embedded in its URL
+	name string
+
+	mu                  sync.Mutex
+	client              *ethclient.Client
+	requests            int64
+	failures            int64
+	consecutiveFailures int
+	openUntil           time.Time
+	avgLatency          time.Duration
+	lastLatency         time.Duration
+	lastErr             error
+	lastCheck           time.Time
+	blockNumber         uint64
+}
+
+// newEndpoint connects to the endpoint. An unreachable endpoint doesn't fail the client:
+// its circuit starts open, and the health checks connect it once it is reachable.
+func newEndpoint(config Endpoint) (*endpoint, error) {
+	u, err := url.Parse(config.URL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid Ethereum RPC endpoint URL")
+	}
+
+	if config.Weight <= 0 {
+		config.Weight = 1
+	}
+
+	ep := &endpoint{
+		Endpoint: config,
+		name:     u.Host,
+	}
+
+	client, err := ethclient.Dial(config.URL)
+	if err != nil {
+		log.Printf("Warning: failed to connect to Ethereum node %s: %v", ep.name, err)
+		ep.failures = 1
+		ep.consecutiveFailures = breakerThreshold
+		ep.lastErr = err
+		ep.openUntil = time.Now().Add(breakerCooldown)
+		return ep, nil
+	}
+	ep.client = client
+
+	return ep, nil
+}
+
+// conn returns the current connection, or nil if the endpoint was never connected
+func (ep *endpoint) conn() *ethclient.Client {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	return ep.client
+}
+
+// available reports whether the endpoint's circuit accepts requests
+func (ep *endpoint) available(now time.Time) bool {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	return !now.Before(ep.openUntil)
+}
+
+// record updates the statistics and the circuit breaker with the outcome of a request
+func (ep *endpoint) record(latency time.Duration, err error) {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+
+	ep.requests++
+	ep.lastLatency = latency
+	if ep.avgLatency == 0 {
+		ep.avgLatency = latency
+	} else {
+		ep.avgLatency = time.Duration(latencySmoothing*float64(latency) + (1-latencySmoothing)*float64(ep.avgLatency))
+	}
+
+	if !isFailoverError(err) {
+		ep.consecutiveFailures = 0
+		ep.openUntil = time.Time{}
+		ep.lastErr = nil
+		return
+	}
+
+	ep.failures++
+	ep.consecutiveFailures++
+	ep.lastErr = err
+	if ep.consecutiveFailures >= breakerThreshold {
+		ep.openUntil = time.Now().Add(breakerCooldown)
+	}
+}
+
+// check pings the endpoint, or connects it if it was never connected
+func (ep *endpoint) check() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := ep.conn()
+	if client == nil {
+		ep.connect()
+		return
+	}
+
+	start := time.Now()
+	blockNumber, err := client.BlockNumber(ctx)
+	ep.record(time.Since(start), err)
+
+	ep.mu.Lock()
+	ep.lastCheck = time.Now()
+	if err == nil {
+		ep.blockNumber = blockNumber
+	}
+	ep.mu.Unlock()
+
+	if err != nil {
+		log.Printf("Warning: health check of Ethereum node %s failed: %v", ep.name, err)
+	}
+}
+
+// connect connects an endpoint that was unreachable at startup. Connections are never
+// replaced once established, since requests may still use them; go-ethereum reconnects
+// broken websocket and IPC connections by itself on the next request.
+func (ep *endpoint) connect() {
+	start := time.Now()
+	client, err := ethclient.Dial(ep.URL)
+	if err != nil {
+		ep.record(time.Since(start), err)
+		log.Printf("Warning: failed to connect to Ethereum node %s: %v", ep.name, err)
+		return
+	}
+
+	ep.mu.Lock()
+	ep.client = client
+	ep.lastCheck = time.Now()
+	ep.mu.Unlock()
+	log.Printf("Connected to Ethereum node %s", ep.name)
+}
+
+// stats returns a snapshot of the endpoint's statistics
+func (ep *endpoint) stats() EndpointStats {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+
+	stats := EndpointStats{
+		Name:                ep.name,
+		Priority:            ep.Priority,
+		Weight:              ep.Weight,
+		State:               stateClosed,
+		Requests:            ep.requests,
+		Failures:            ep.failures,
+		ConsecutiveFailures: ep.consecutiveFailures,
+		AvgLatency:          ep.avgLatency.String(),
+		LastLatency:         ep.lastLatency.String(),
+		BlockNumber:         ep.blockNumber,
+	}
+	if time.Now().Before(ep.openUntil) {
+		stats.State = stateOpen
+	}
+	if ep.lastErr != nil {
+		stats.LastError = ep.lastErr.Error()
+	}
+	if !ep.lastCheck.IsZero() {
+		stats.LastCheck = ep.lastCheck.Format(time.RFC3339)
+	}
+
+	return stats
+}
+
+// candidates returns the endpoints to try for a request. Available endpoints come
+// first, ordered by priority and shuffled by weight within the same priority.
+// Endpoints with an open circuit are kept as a last resort.
+func (c *Client) candidates() []*endpoint {
+	now := time.Now()
+
+	var available, open []*endpoint
+	for _, ep := range c.endpoints {
+		if ep.available(now) {
+			available = append(available, ep)
+		} else {
+			open = append(open, ep)
+		}
+	}
+
+	ordered := weightedShuffle(available)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Priority < ordered[j].Priority })
+	sort.SliceStable(open, func(i, j int) bool { return open[i].Priority < open[j].Priority })
+
+	return append(ordered, open...)
+}
+
+// weightedShuffle returns the endpoints in a random order where heavier endpoints tend to come first
+func weightedShuffle(endpoints []*endpoint) []*endpoint {
+	remaining := append([]*endpoint(nil), endpoints...)
+	shuffled := make([]*endpoint, 0, len(endpoints))
+
+	for len(remaining) > 0 {
+		total := 0
+		for _, ep := range remaining {
+			total += ep.Weight
+		}
+
+		pick := rand.IntN(total)
+		for i, ep := range remaining {
+			if pick < ep.Weight {
+				shuffled = append(shuffled, ep)
+				remaining = append(remaining[:i], remaining[i+1:]...)
+				break
+			}
+			pick -= ep.Weight
+		}
+	}
+
+	return shuffled
+}
+
+// isFailoverError reports whether err was caused by the endpoint rather than the
+// request itself, so that the request should be retried on another endpoint.
+// This covers transport errors, HTTP error statuses and rate-limit responses.
+func isFailoverError(err error) bool {
+	if err == nil || errors.Is(err, ethereum.NotFound) || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		// -32005 is the "limit exceeded" code used by most providers; some return 429 directly
+		return rpcErr.ErrorCode() == -32005 || rpcErr.ErrorCode() == 429
+	}
+
+	return true
+}
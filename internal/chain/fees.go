@@ -0,0 +1,185 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// feeHistoryBlocks is the number of recent blocks priority fees are estimated from
+const feeHistoryBlocks = 20
+
+// gasLimitMarginPercent is added to estimated gas, since state can change before the transaction is mined
+const gasLimitMarginPercent = 20
+
+// feeBumpPercent is how much a replacement raises both fees; nodes require at least 10%
+const feeBumpPercent = 15
+
+// ErrFeeTooHigh is returned when the network requires a higher fee than the configured caps allow
+var ErrFeeTooHigh = errors.New("network fees exceed the configured maximum")
+
+// ErrExecutionReverted is returned when gas estimation fails because the call would revert
+var ErrExecutionReverted = errors.New("execution reverted")
+
+// revertedErrorCode is the JSON-RPC error code nodes use for reverted calls
+const revertedErrorCode = 3
+
+// FeePolicy trades confirmation speed for cost
+type FeePolicy string
+
+// Supported fee policies
+const (
+	FeePolicySlow     FeePolicy = "slow"
+	FeePolicyStandard FeePolicy = "standard"
+	FeePolicyFast     FeePolicy = "fast"
+)
+
+// feePolicyParams configures the estimation of a fee policy
+type feePolicyParams struct {
+	// rewardPercentile is the percentile of recent priority fees the tip is taken from
+	rewardPercentile float64
+	// baseFeeMultiplier is how many times the current base fee the fee cap covers,
+	// so the transaction stays includable while the base fee rises
+	baseFeeMultiplier int64
+}
+
+var feePolicies = map[FeePolicy]feePolicyParams{
+	FeePolicySlow:     {rewardPercentile: 10, baseFeeMultiplier: 1},
+	FeePolicyStandard: {rewardPercentile: 50, baseFeeMultiplier: 2},
+	FeePolicyFast:     {rewardPercentile: 90, baseFeeMultiplier: 3},
+}
+
+// ParseFeePolicy returns the fee policy with the given name, or the standard policy if it is empty
+func ParseFeePolicy(name string) (FeePolicy, error) {
+	if name == "" {
+		return FeePolicyStandard, nil
+	}
+	if _, ok := feePolicies[FeePolicy(name)]; !ok {
+		return "", fmt.Errorf("unknown fee policy %q, must be one of slow, standard, fast", name)
+	}
+	return FeePolicy(name), nil
+}
+
+// FeeCaps bounds the fees of transactions. Nil caps are unlimited.
+type FeeCaps struct {
+	// MaxFeePerGas rejects transactions when the base fee plus tip exceeds it, and caps the fee cap otherwise
+	MaxFeePerGas *big.Int
+	// MaxPriorityFeePerGas caps the tip
+	MaxPriorityFeePerGas *big.Int
+}
+
+// Fees are the EIP-1559 fees of a dynamic fee transaction
+type Fees struct {
+	GasTipCap *big.Int
+	GasFeeCap *big.Int
+}
+
+// FeeBackend is the subset of the Ethereum JSON-RPC API used to estimate fees
+type FeeBackend interface {
+	FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error)
+	EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error)
+}
+
+// EstimateFees estimates the fees of a transaction from the fee history of recent blocks.
+// The tip is the median of the policy's percentile of recent priority fees, and the fee cap
+// covers a multiple of the base fee of the next block plus the tip.
+func EstimateFees(ctx context.Context, backend FeeBackend, policy FeePolicy, caps FeeCaps) (*Fees, error) {
+	params, ok := feePolicies[policy]
+	if !ok {
+		return nil, fmt.Errorf("unknown fee policy %q", policy)
+	}
+
+	history, err := backend.FeeHistory(ctx, feeHistoryBlocks, nil, []float64{params.rewardPercentile})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fee history: %w", err)
+	}
+	// The base fee history includes the base fee of the next block
+	if len(history.BaseFee) == 0 {
+		return nil, errors.New("fee history is empty")
+	}
+	baseFee := history.BaseFee[len(history.BaseFee)-1]
+
+	var rewards []*big.Int
+	for _, reward := range history.Reward {
+		if len(reward) > 0 && reward[0] != nil {
+			rewards = append(rewards, reward[0])
+		}
+	}
+	tip := new(big.Int)
+	if len(rewards) > 0 {
+		slices.SortFunc(rewards, func(a, b *big.Int) int { return a.Cmp(b) })
+		tip.Set(rewards[len(rewards)/2])
+	}
+	if caps.MaxPriorityFeePerGas != nil && tip.Cmp(caps.MaxPriorityFeePerGas) > 0 {
+		tip.Set(caps.MaxPriorityFeePerGas)
+	}
+
+	feeCap := new(big.Int).Mul(baseFee, big.NewInt(params.baseFeeMultiplier))
+	feeCap.Add(feeCap, tip)
+	if caps.MaxFeePerGas != nil && feeCap.Cmp(caps.MaxFeePerGas) > 0 {
+		if required := new(big.Int).Add(baseFee, tip); required.Cmp(caps.MaxFeePerGas) > 0 {
+			return nil, fmt.Errorf("%w: base fee %s plus tip %s wei is above %s wei", ErrFeeTooHigh, baseFee, tip, caps.MaxFeePerGas)
+		}
+		feeCap.Set(caps.MaxFeePerGas)
+	}
+
+	return &Fees{GasTipCap: tip, GasFeeCap: feeCap}, nil
+}
+
+// BumpFees returns the fees of a transaction replacing one with the current fees. Both fees
+// are raised enough for nodes to accept the replacement, and at least to the fresh estimate.
+// Caps are not clamped to, since a lower fee would be rejected as underpriced.
+func BumpFees(current, fresh *Fees, caps FeeCaps) (*Fees, error) {
+	bump := func(fee *big.Int) *big.Int {
+		bumped := new(big.Int).Mul(fee, big.NewInt(100+feeBumpPercent))
+		return bumped.Add(bumped, big.NewInt(99)).Div(bumped, big.NewInt(100))
+	}
+
+	fees := &Fees{GasTipCap: bump(current.GasTipCap), GasFeeCap: bump(current.GasFeeCap)}
+	if fresh != nil {
+		if fresh.GasTipCap.Cmp(fees.GasTipCap) > 0 {
+			fees.GasTipCap.Set(fresh.GasTipCap)
+		}
+		if fresh.GasFeeCap.Cmp(fees.GasFeeCap) > 0 {
+			fees.GasFeeCap.Set(fresh.GasFeeCap)
+		}
+	}
+	if fees.GasTipCap.Cmp(fees.GasFeeCap) > 0 {
+		fees.GasFeeCap.Set(fees.GasTipCap)
+	}
+
+	if caps.MaxFeePerGas != nil && fees.GasFeeCap.Cmp(caps.MaxFeePerGas) > 0 {
+		return nil, fmt.Errorf("%w: replacement fee cap %s wei is above %s wei", ErrFeeTooHigh, fees.GasFeeCap, caps.MaxFeePerGas)
+	}
+	if caps.MaxPriorityFeePerGas != nil && fees.GasTipCap.Cmp(caps.MaxPriorityFeePerGas) > 0 {
+		return nil, fmt.Errorf("%w: replacement tip %s wei is above %s wei", ErrFeeTooHigh, fees.GasTipCap, caps.MaxPriorityFeePerGas)
+	}
+	return fees, nil
+}
+
+// EstimateGasLimit estimates the gas used by a call and adds a safety margin
+func EstimateGasLimit(ctx context.Context, backend FeeBackend, msg ethereum.CallMsg) (uint64, error) {
+	gas, err := backend.EstimateGas(ctx, msg)
+	if err != nil {
+		if isReverted(err) {
+			return 0, fmt.Errorf("%w: %w", ErrExecutionReverted, err)
+		}
+		return 0, fmt.Errorf("failed to estimate gas: %w", err)
+	}
+	return gas + gas*gasLimitMarginPercent/100, nil
+}
+
+// isReverted reports whether a call failed because it reverted, rather than because of the node
+func isReverted(err error) bool {
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == revertedErrorCode {
+		return true
+	}
+	return strings.Contains(strings.ToLower(err.Error()), "execution reverted")
+}
@@ -0,0 +1,122 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+)
+
+// fakeFeeBackend serves a fixed fee history with one reward per block
+type fakeFeeBackend struct {
+	baseFee *big.Int
+	rewards []int64
+	gas     uint64
+	gasErr  error
+}
+
+func (b *fakeFeeBackend) FeeHistory(_ context.Context, _ uint64, _ *big.Int, _ []float64) (*ethereum.FeeHistory, error) {
+	history := &ethereum.FeeHistory{}
+	for _, reward := range b.rewards {
+		history.Reward = append(history.Reward, []*big.Int{big.NewInt(reward)})
+		history.BaseFee = append(history.BaseFee, b.baseFee)
+	}
+	history.BaseFee = append(history.BaseFee, b.baseFee)
+	return history, nil
+}
+
+func (b *fakeFeeBackend) EstimateGas(context.Context, ethereum.CallMsg) (uint64, error) {
+	return b.gas, b.gasErr
+}
+
+func TestEstimateFees(t *testing.T) {
+	backend := &fakeFeeBackend{baseFee: big.NewInt(100), rewards: []int64{5, 1, 3, 2, 4}}
+
+	tests := []struct {
+		name       string
+		policy     FeePolicy
+		caps       FeeCaps
+		wantTip    int64
+		wantFeeCap int64
+		wantErr    error
+	}{
+		{name: "slow", policy: FeePolicySlow, wantTip: 3, wantFeeCap: 103},
+		{name: "standard", policy: FeePolicyStandard, wantTip: 3, wantFeeCap: 203},
+		{name: "fast", policy: FeePolicyFast, wantTip: 3, wantFeeCap: 303},
+		{name: "tip capped", policy: FeePolicyStandard, caps: FeeCaps{MaxPriorityFeePerGas: big.NewInt(2)}, wantTip: 2, wantFeeCap: 202},
+		{name: "fee cap capped", policy: FeePolicyStandard, caps: FeeCaps{MaxFeePerGas: big.NewInt(150)}, wantTip: 3, wantFeeCap: 150},
+		{name: "too expensive", policy: FeePolicyStandard, caps: FeeCaps{MaxFeePerGas: big.NewInt(102)}, wantErr: ErrFeeTooHigh},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fees, err := EstimateFees(context.Background(), backend, tt.policy, tt.caps)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("EstimateFees() returned error: %v", err)
+			}
+			if fees.GasTipCap.Int64() != tt.wantTip {
+				t.Errorf("expected tip %d, got %s", tt.wantTip, fees.GasTipCap)
+			}
+			if fees.GasFeeCap.Int64() != tt.wantFeeCap {
+				t.Errorf("expected fee cap %d, got %s", tt.wantFeeCap, fees.GasFeeCap)
+			}
+		})
+	}
+}
+
+func TestEstimateGasLimit(t *testing.T) {
+	gas, err := EstimateGasLimit(context.Background(), &fakeFeeBackend{gas: 50000}, ethereum.CallMsg{})
+	if err != nil {
+		t.Fatalf("EstimateGasLimit() returned error: %v", err)
+	}
+	if gas != 60000 {
+		t.Errorf("expected gas limit 60000, got %d", gas)
+	}
+
+	// Reverts are told apart from failures of the node
+	reverted := &fakeFeeBackend{gasErr: errors.New("execution reverted: age must be positive")}
+	if _, err := EstimateGasLimit(context.Background(), reverted, ethereum.CallMsg{}); !errors.Is(err, ErrExecutionReverted) {
+		t.Errorf("expected ErrExecutionReverted, got %v", err)
+	}
+	unavailable := &fakeFeeBackend{gasErr: errors.New("connection refused")}
+	if _, err := EstimateGasLimit(context.Background(), unavailable, ethereum.CallMsg{}); err == nil || errors.Is(err, ErrExecutionReverted) {
+		t.Errorf("expected a non-revert error, got %v", err)
+	}
+}
+
+func TestParseFeePolicy(t *testing.T) {
+	if policy, err := ParseFeePolicy(""); err != nil || policy != FeePolicyStandard {
+		t.Errorf("expected standard policy by default, got %q, %v", policy, err)
+	}
+	if policy, err := ParseFeePolicy("fast"); err != nil || policy != FeePolicyFast {
+		t.Errorf("expected fast policy, got %q, %v", policy, err)
+	}
+	if _, err := ParseFeePolicy("instant"); err == nil {
+		t.Errorf("expected error for an unknown policy")
+	}
+}
+
+func TestBumpFees(t *testing.T) {
+	current := &Fees{GasTipCap: big.NewInt(10), GasFeeCap: big.NewInt(200)}
+
+	fees, err := BumpFees(current, &Fees{GasTipCap: big.NewInt(5), GasFeeCap: big.NewInt(300)}, FeeCaps{})
+	if err != nil {
+		t.Fatalf("BumpFees() returned error: %v", err)
+	}
+	// The tip is bumped by 15%, rounded up, while the fresh fee cap is higher than the bumped one
+	if fees.GasTipCap.Int64() != 12 || fees.GasFeeCap.Int64() != 300 {
+		t.Errorf("expected fees 12/300, got %s/%s", fees.GasTipCap, fees.GasFeeCap)
+	}
+
+	if _, err := BumpFees(current, nil, FeeCaps{MaxFeePerGas: big.NewInt(220)}); !errors.Is(err, ErrFeeTooHigh) {
+		t.Errorf("expected ErrFeeTooHigh above the fee cap, got %v", err)
+	}
+}
@@ -0,0 +1,282 @@
+package chain
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// NonceStore persists the next nonce of accounts, so allocations survive restarts
+type NonceStore interface {
+	// GetNonce returns the next nonce of the account, and false if none is stored
+	GetNonce(ctx context.Context, account common.Address) (uint64, bool, error)
+	PutNonce(ctx context.Context, account common.Address, next uint64) error
+	// HasPending reports whether transactions of the account with a nonce at or above
+	// the given one may still be pending, even if the node doesn't know them
+	HasPending(ctx context.Context, account common.Address, nonce uint64) (bool, error)
+}
+
+// NonceBackend is the subset of the Ethereum JSON-RPC API used to sync nonces
+type NonceBackend interface {
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+}
+
+// accountNonces is the allocation state of a single account
+type accountNonces struct {
+	// mu serializes allocations of the account, including the calls to the node and store they make
+	mu     sync.Mutex
+	synced bool
+	next   uint64
+	// released are allocated nonces that were never sent, to be reused before next
+	released []uint64
+
+	// inflight are allocated nonces that are being signed and sent, guarded by NonceManager.mu
+	inflight map[uint64]bool
+}
+
+// NonceManager allocates nonces for the server's signing accounts. Allocation is serialized per
+// account, so concurrent transactions never share a nonce, and nonces of transactions that failed
+// to be sent are reused, so a failure doesn't leave a gap that blocks later transactions.
+type NonceManager struct {
+	backend NonceBackend
+	store   NonceStore
+
+	// mu guards accounts and the in-flight nonces of each account. It is never held
+	// while calling the node or the store, so Done doesn't wait for a slow allocation.
+	mu       sync.Mutex
+	accounts map[common.Address]*accountNonces
+}
+
+// NewNonceManager creates a NonceManager persisting nonces in the store
+func NewNonceManager(backend NonceBackend, store NonceStore) *NonceManager {
+	return &NonceManager{
+		backend:  backend,
+		store:    store,
+		accounts: make(map[common.Address]*accountNonces),
+	}
+}
+
+// account returns the allocation state of the account, creating it if needed
+func (m *NonceManager) account(account common.Address) *accountNonces {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, ok := m.accounts[account]
+	if !ok {
+		state = &accountNonces{inflight: make(map[uint64]bool)}
+		m.accounts[account] = state
+	}
+	return state
+}
+
+// Sync resets the account to the pending nonce of the chain, or to the stored nonce if it is
+// ahead and transactions using the nonces in between may still be pending. Released nonces
+// are dropped, while nonces that are still in flight are kept. It is called on startup and
+// after the network rejected a nonce, to recover from transactions sent by other means or lost.
+func (m *NonceManager) Sync(ctx context.Context, account common.Address) error {
+	state := m.account(account)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	return m.sync(ctx, account, state)
+}
+
+// sync implements Sync. The caller must hold the account's lock.
+func (m *NonceManager) sync(ctx context.Context, account common.Address, state *accountNonces) error {
+	pending, err := m.backend.PendingNonceAt(ctx, account)
+	if err != nil {
+		return fmt.Errorf("failed to get pending nonce: %w", err)
+	}
+
+	stored, found, err := m.store.GetNonce(ctx, account)
+	if err != nil {
+		return fmt.Errorf("failed to load nonce: %w", err)
+	}
+	// Nonces stored past the pending nonce are only reused once none of their transactions
+	// can still be pending, since the node may have lost them, e.g. after its own restart
+	next := pending
+	if found && stored > pending {
+		inUse, err := m.store.HasPending(ctx, account, pending)
+		if err != nil {
+			return fmt.Errorf("failed to check pending transactions: %w", err)
+		}
+		if inUse {
+			next = stored
+		} else {
+			log.Printf("Reusing nonces %d to %d of %s that never reached the network", pending, stored-1, account.Hex())
+		}
+	}
+
+	m.mu.Lock()
+	for nonce := range state.inflight {
+		next = max(next, nonce+1)
+	}
+	m.mu.Unlock()
+
+	if err := m.store.PutNonce(ctx, account, next); err != nil {
+		return fmt.Errorf("failed to store nonce: %w", err)
+	}
+	state.next = next
+	state.released = nil
+	state.synced = true
+	return nil
+}
+
+// Next allocates a nonce for the account. The caller must report the outcome with Done or Release.
+func (m *NonceManager) Next(ctx context.Context, account common.Address) (uint64, error) {
+	state := m.account(account)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if !state.synced {
+		if err := m.sync(ctx, account, state); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := m.dropUsed(ctx, account, state); err != nil {
+		return 0, err
+	}
+	if len(state.released) > 0 {
+		nonce := state.released[0]
+		state.released = state.released[1:]
+		m.setInflight(state, nonce, true)
+		return nonce, nil
+	}
+
+	nonce := state.next
+	if err := m.store.PutNonce(ctx, account, nonce+1); err != nil {
+		return 0, fmt.Errorf("failed to store nonce: %w", err)
+	}
+	state.next = nonce + 1
+	m.setInflight(state, nonce, true)
+	return nonce, nil
+}
+
+// TakeReleased allocates all released nonces of the account at once. Released nonces are
+// below the next nonce, so until they are used, every later transaction is stuck behind them.
+// The caller must report the outcome of each nonce with Done or Release.
+func (m *NonceManager) TakeReleased(ctx context.Context, account common.Address) ([]uint64, error) {
+	state := m.account(account)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if err := m.dropUsed(ctx, account, state); err != nil {
+		return nil, err
+	}
+	nonces := state.released
+	state.released = nil
+	for _, nonce := range nonces {
+		m.setInflight(state, nonce, true)
+	}
+	return nonces, nil
+}
+
+// dropUsed drops the released nonces that were used meanwhile by a transaction sent by other
+// means. The caller must hold the account's lock.
+func (m *NonceManager) dropUsed(ctx context.Context, account common.Address, state *accountNonces) error {
+	if len(state.released) == 0 {
+		return nil
+	}
+	mined, err := m.backend.NonceAt(ctx, account, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get nonce: %w", err)
+	}
+	state.released = slices.DeleteFunc(state.released, func(nonce uint64) bool { return nonce < mined })
+	return nil
+}
+
+// setInflight marks a nonce of the account as in flight or not, and reports whether it was
+func (m *NonceManager) setInflight(state *accountNonces, nonce uint64, inflight bool) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	was := state.inflight[nonce]
+	if inflight {
+		state.inflight[nonce] = true
+	} else {
+		delete(state.inflight, nonce)
+	}
+	return was
+}
+
+// Done marks an allocated nonce as used by a sent transaction
+func (m *NonceManager) Done(account common.Address, nonce uint64) {
+	m.mu.Lock()
+	state, ok := m.accounts[account]
+	m.mu.Unlock()
+
+	if ok {
+		m.setInflight(state, nonce, false)
+	}
+}
+
+// Release returns an allocated nonce that was not used, so it is allocated again
+func (m *NonceManager) Release(ctx context.Context, account common.Address, nonce uint64) error {
+	state := m.account(account)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if !m.setInflight(state, nonce, false) {
+		return nil
+	}
+
+	i, found := slices.BinarySearch(state.released, nonce)
+	if !found {
+		state.released = slices.Insert(state.released, i, nonce)
+	}
+
+	// Released nonces at the end are handed out again by moving next back
+	n := len(state.released)
+	for n > 0 && state.released[n-1] == state.next-1 {
+		state.next--
+		n--
+	}
+	if n == len(state.released) {
+		return nil
+	}
+	state.released = state.released[:n]
+	return m.store.PutNonce(ctx, account, state.next)
+}
+
+// IsNonceTooLow reports whether the network rejected a transaction because its nonce was already used
+func IsNonceTooLow(err error) bool {
+	return err != nil && strings.Contains(strings.ToLower(err.Error()), "nonce too low")
+}
+
+// rejectionErrors are the errors of nodes refusing a transaction, so it will never be mined
+var rejectionErrors = []string{
+	"nonce too low",
+	"nonce too high",
+	"underpriced",
+	"insufficient funds",
+	"intrinsic gas too low",
+	"exceeds block gas limit",
+	"exceeds the configured cap",
+	"max fee per gas less than block base fee",
+	"max priority fee per gas higher than max fee per gas",
+	"invalid sender",
+	"invalid chain id",
+	"negative value",
+	"oversized data",
+	"transaction type not supported",
+}
+
+// IsRejected reports whether the network definitely refused a transaction. Other errors, like
+// timeouts or "already known", leave open whether the transaction reached the network.
+func IsRejected(err error) bool {
+	if err == nil {
+		return false
+	}
+	message := strings.ToLower(err.Error())
+	return slices.ContainsFunc(rejectionErrors, func(rejection string) bool {
+		return strings.Contains(message, rejection)
+	})
+}
@@ -0,0 +1,300 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// fakeNonceBackend reports fixed mined and pending nonces
+type fakeNonceBackend struct {
+	mined, pending uint64
+}
+
+func (b *fakeNonceBackend) NonceAt(context.Context, common.Address, *big.Int) (uint64, error) {
+	return b.mined, nil
+}
+
+func (b *fakeNonceBackend) PendingNonceAt(context.Context, common.Address) (uint64, error) {
+	return b.pending, nil
+}
+
+// memoryNonceStore is a NonceStore keeping nonces in memory
+type memoryNonceStore struct {
+	mu     sync.Mutex
+	nonces map[common.Address]uint64
+	// pending are the nonces of transactions that may still be pending
+	pending []uint64
+}
+
+func (s *memoryNonceStore) GetNonce(_ context.Context, account common.Address) (uint64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	nonce, ok := s.nonces[account]
+	return nonce, ok, nil
+}
+
+func (s *memoryNonceStore) PutNonce(_ context.Context, account common.Address, next uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nonces[account] = next
+	return nil
+}
+
+func (s *memoryNonceStore) HasPending(_ context.Context, _ common.Address, nonce uint64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.ContainsFunc(s.pending, func(pending uint64) bool { return pending >= nonce }), nil
+}
+
+var testAccount = common.HexToAddress("0x5B38Da6a701c568545dCfcB03FcB875f56beddC4")
+
+func TestNonceManagerConcurrentAllocation(t *testing.T) {
+	store := &memoryNonceStore{nonces: make(map[common.Address]uint64)}
+	m := NewNonceManager(&fakeNonceBackend{mined: 7, pending: 7}, store)
+
+	const n = 50
+	var wg sync.WaitGroup
+	nonces := make(chan uint64, n)
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nonce, err := m.Next(context.Background(), testAccount)
+			if err != nil {
+				t.Errorf("Next() returned error: %v", err)
+				return
+			}
+			m.Done(testAccount, nonce)
+			nonces <- nonce
+		}()
+	}
+	wg.Wait()
+	close(nonces)
+
+	seen := make(map[uint64]bool)
+	for nonce := range nonces {
+		if seen[nonce] {
+			t.Errorf("nonce %d allocated twice", nonce)
+		}
+		seen[nonce] = true
+	}
+	for nonce := uint64(7); nonce < 7+n; nonce++ {
+		if !seen[nonce] {
+			t.Errorf("nonce %d was skipped", nonce)
+		}
+	}
+	if store.nonces[testAccount] != 7+n {
+		t.Errorf("expected stored nonce %d, got %d", 7+n, store.nonces[testAccount])
+	}
+}
+
+func TestNonceManagerReleaseFillsGaps(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryNonceStore{nonces: make(map[common.Address]uint64)}
+	m := NewNonceManager(&fakeNonceBackend{pending: 0}, store)
+
+	var allocated []uint64
+	for range 3 {
+		nonce, err := m.Next(ctx, testAccount)
+		if err != nil {
+			t.Fatalf("Next() returned error: %v", err)
+		}
+		allocated = append(allocated, nonce)
+	}
+
+	// The middle transaction fails, so its nonce is reused before a new one
+	m.Done(testAccount, allocated[0])
+	m.Done(testAccount, allocated[2])
+	if err := m.Release(ctx, testAccount, allocated[1]); err != nil {
+		t.Fatalf("Release() returned error: %v", err)
+	}
+	if nonce, _ := m.Next(ctx, testAccount); nonce != 1 {
+		t.Errorf("expected released nonce 1, got %d", nonce)
+	}
+	if nonce, _ := m.Next(ctx, testAccount); nonce != 3 {
+		t.Errorf("expected nonce 3, got %d", nonce)
+	}
+
+	// Releasing the latest nonce moves the next nonce back
+	if err := m.Release(ctx, testAccount, 3); err != nil {
+		t.Fatalf("Release() returned error: %v", err)
+	}
+	if store.nonces[testAccount] != 3 {
+		t.Errorf("expected stored nonce 3, got %d", store.nonces[testAccount])
+	}
+}
+
+func TestNonceManagerSync(t *testing.T) {
+	ctx := context.Background()
+
+	// Nonces 5 to 9 were allocated before a restart but never reached the network
+	store := &memoryNonceStore{nonces: map[common.Address]uint64{testAccount: 10}}
+	backend := &fakeNonceBackend{mined: 5, pending: 5}
+	m := NewNonceManager(backend, store)
+
+	if err := m.Sync(ctx, testAccount); err != nil {
+		t.Fatalf("Sync() returned error: %v", err)
+	}
+	if nonce, _ := m.Next(ctx, testAccount); nonce != 5 {
+		t.Errorf("expected nonce 5 after sync, got %d", nonce)
+	}
+
+	// Nonces still in flight are not handed out again, even if the node doesn't know them yet
+	backend.pending = 3
+	if err := m.Sync(ctx, testAccount); err != nil {
+		t.Fatalf("Sync() returned error: %v", err)
+	}
+	if nonce, _ := m.Next(ctx, testAccount); nonce != 6 {
+		t.Errorf("expected nonce 6 after in-flight nonce 5, got %d", nonce)
+	}
+
+	// Transactions sent by other means move the pending nonce past the allocated ones
+	backend.pending = 20
+	if err := m.Sync(ctx, testAccount); err != nil {
+		t.Fatalf("Sync() returned error: %v", err)
+	}
+	if nonce, _ := m.Next(ctx, testAccount); nonce != 20 {
+		t.Errorf("expected nonce 20 after sync, got %d", nonce)
+	}
+}
+
+func TestNonceManagerSyncKeepsPendingNonces(t *testing.T) {
+	ctx := context.Background()
+
+	// Nonces 5 to 9 were broadcast, but the node lost them, e.g. because it restarted
+	store := &memoryNonceStore{nonces: map[common.Address]uint64{testAccount: 10}, pending: []uint64{5, 9}}
+	backend := &fakeNonceBackend{mined: 5, pending: 5}
+	m := NewNonceManager(backend, store)
+
+	if err := m.Sync(ctx, testAccount); err != nil {
+		t.Fatalf("Sync() returned error: %v", err)
+	}
+	nonce, _ := m.Next(ctx, testAccount)
+	if nonce != 10 {
+		t.Errorf("expected nonce 10 past the pending transactions, got %d", nonce)
+	}
+	m.Done(testAccount, nonce)
+
+	// Once none of them can be pending anymore, the nonces are reused
+	store.pending = nil
+	if err := m.Sync(ctx, testAccount); err != nil {
+		t.Fatalf("Sync() returned error: %v", err)
+	}
+	if nonce, _ := m.Next(ctx, testAccount); nonce != 5 {
+		t.Errorf("expected nonce 5 once the transactions were dropped, got %d", nonce)
+	}
+}
+
+func TestNonceManagerTakeReleased(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryNonceStore{nonces: make(map[common.Address]uint64)}
+	backend := &fakeNonceBackend{}
+	m := NewNonceManager(backend, store)
+
+	for range 4 {
+		if _, err := m.Next(ctx, testAccount); err != nil {
+			t.Fatalf("Next() returned error: %v", err)
+		}
+	}
+	m.Done(testAccount, 3)
+	for _, nonce := range []uint64{0, 1, 2} {
+		if err := m.Release(ctx, testAccount, nonce); err != nil {
+			t.Fatalf("Release() returned error: %v", err)
+		}
+	}
+
+	// Nonce 0 was used meanwhile by a transaction sent by other means
+	backend.mined = 1
+	nonces, err := m.TakeReleased(ctx, testAccount)
+	if err != nil {
+		t.Fatalf("TakeReleased() returned error: %v", err)
+	}
+	if !slices.Equal(nonces, []uint64{1, 2}) {
+		t.Errorf("expected released nonces [1 2], got %v", nonces)
+	}
+	if nonce, _ := m.Next(ctx, testAccount); nonce != 4 {
+		t.Errorf("expected nonce 4 once the released nonces were taken, got %d", nonce)
+	}
+
+	// Taken nonces that are released again are reused
+	if err := m.Release(ctx, testAccount, 1); err != nil {
+		t.Fatalf("Release() returned error: %v", err)
+	}
+	if nonce, _ := m.Next(ctx, testAccount); nonce != 1 {
+		t.Errorf("expected released nonce 1, got %d", nonce)
+	}
+}
+
+// blockingNonceBackend is a fakeNonceBackend whose pending nonce lookups of one account block until unblocked
+type blockingNonceBackend struct {
+	fakeNonceBackend
+	account common.Address
+	called  chan struct{}
+	unblock chan struct{}
+}
+
+func (b *blockingNonceBackend) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	if account == b.account {
+		close(b.called)
+		<-b.unblock
+	}
+	return b.fakeNonceBackend.PendingNonceAt(ctx, account)
+}
+
+func TestNonceManagerSlowAllocationDoesNotBlock(t *testing.T) {
+	ctx := context.Background()
+	slowAccount := common.HexToAddress("0x01")
+	backend := &blockingNonceBackend{account: slowAccount, called: make(chan struct{}), unblock: make(chan struct{})}
+	m := NewNonceManager(backend, &memoryNonceStore{nonces: make(map[common.Address]uint64)})
+
+	nonce, err := m.Next(ctx, testAccount)
+	if err != nil {
+		t.Fatalf("Next() returned error: %v", err)
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := m.Next(ctx, slowAccount)
+		done <- err
+	}()
+	<-backend.called
+
+	// While the slow account waits for the node, other accounts and outcomes are not held up
+	m.Done(testAccount, nonce)
+	if _, err := m.Next(ctx, testAccount); err != nil {
+		t.Errorf("Next() returned error: %v", err)
+	}
+
+	close(backend.unblock)
+	if err := <-done; err != nil {
+		t.Errorf("Next() returned error: %v", err)
+	}
+}
+
+func TestIsRejected(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{errors.New("nonce too low: next nonce 5, tx nonce 4"), true},
+		{errors.New("replacement transaction underpriced"), true},
+		{errors.New("insufficient funds for gas * price + value"), true},
+		{errors.New("already known"), false},
+		{fmt.Errorf("failed to send: %w", context.DeadlineExceeded), false},
+		{errors.New("dial tcp 10.0.0.1:8545: connection refused"), false},
+		{nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := IsRejected(tt.err); got != tt.want {
+			t.Errorf("IsRejected(%v) = %t, want %t", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package chain
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Signer signs transactions for a single account
+type Signer interface {
+	// Address returns the account transactions are signed for
+	Address() common.Address
+	// SignTx signs the transaction for the chain
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// KeySigner is a Signer holding the private key of the account in memory
+type KeySigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+// NewKeySigner creates a KeySigner for the private key
+func NewKeySigner(key *ecdsa.PrivateKey) *KeySigner {
+	return &KeySigner{key: key, address: crypto.PubkeyToAddress(key.PublicKey)}
+}
+
+// NewHexKeySigner creates a KeySigner for a hex encoded private key
+func NewHexKeySigner(hexKey string) (*KeySigner, error) {
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(hexKey, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	return NewKeySigner(key), nil
+}
+
+// NewKeystoreSigner creates a KeySigner for an encrypted go-ethereum keystore file,
+// decrypted with the password in passwordFile
+func NewKeystoreSigner(keystoreFile, passwordFile string) (*KeySigner, error) {
+	keyJSON, err := os.ReadFile(keystoreFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore file: %w", err)
+	}
+	password, err := os.ReadFile(passwordFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read password file: %w", err)
+	}
+
+	// Password files usually end with a newline, which is not part of the password
+	key, err := keystore.DecryptKey(keyJSON, strings.TrimRight(string(password), "\r\n"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt keystore file: %w", err)
+	}
+	return NewKeySigner(key.PrivateKey), nil
+}
+
+// Address implements Signer
+func (s *KeySigner) Address() common.Address {
+	return s.address
+}
+
+// SignTx implements Signer
+func (s *KeySigner) SignTx(_ context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), s.key)
+}
+
+// RemoteSigner is a Signer delegating to an external signer with the eth_signTransaction
+// JSON-RPC method, such as Clef. The key never leaves the external signer.
+type RemoteSigner struct {
+	client  *rpc.Client
+	address common.Address
+}
+
+// NewRemoteSigner creates a RemoteSigner signing for the address with the signer at url
+func NewRemoteSigner(ctx context.Context, url string, address common.Address) (*RemoteSigner, error) {
+	client, err := rpc.DialContext(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to remote signer: %w", err)
+	}
+	return &RemoteSigner{client: client, address: address}, nil
+}
+
+// Address implements Signer
+func (s *RemoteSigner) Address() common.Address {
+	return s.address
+}
+
+// signTransactionArgs are the arguments of eth_signTransaction
+type signTransactionArgs struct {
+	From                 common.Address  `json:"from"`
+	To                   *common.Address `json:"to,omitempty"`
+	Gas                  hexutil.Uint64  `json:"gas"`
+	GasPrice             *hexutil.Big    `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas,omitempty"`
+	Value                hexutil.Big     `json:"value"`
+	Nonce                hexutil.Uint64  `json:"nonce"`
+	Input                hexutil.Bytes   `json:"input"`
+	ChainID              *hexutil.Big    `json:"chainId,omitempty"`
+}
+
+// signTransactionResult is the result of eth_signTransaction
+type signTransactionResult struct {
+	Raw hexutil.Bytes `json:"raw"`
+}
+
+// SignTx implements Signer. The signed transaction is checked against the request,
+// so a misbehaving signer can't make the server send something else.
+func (s *RemoteSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	args := signTransactionArgs{
+		From:    s.address,
+		To:      tx.To(),
+		Gas:     hexutil.Uint64(tx.Gas()),
+		Value:   hexutil.Big(*tx.Value()),
+		Nonce:   hexutil.Uint64(tx.Nonce()),
+		Input:   tx.Data(),
+		ChainID: (*hexutil.Big)(chainID),
+	}
+	if tx.Type() == types.LegacyTxType {
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	} else {
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+	}
+
+	var result signTransactionResult
+	if err := s.client.CallContext(ctx, &result, "eth_signTransaction", args); err != nil {
+		return nil, fmt.Errorf("remote signer failed: %w", err)
+	}
+
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(result.Raw); err != nil {
+		return nil, fmt.Errorf("remote signer returned an invalid transaction: %w", err)
+	}
+
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+	if err != nil {
+		return nil, fmt.Errorf("remote signer returned an invalid signature: %w", err)
+	}
+	if sender != s.address {
+		return nil, fmt.Errorf("remote signer signed for %s instead of %s", sender.Hex(), s.address.Hex())
+	}
+	if !sameTransaction(tx, signed) {
+		return nil, errors.New("remote signer returned a different transaction")
+	}
+	return signed, nil
+}
+
+// Close closes the connection to the remote signer
+func (s *RemoteSigner) Close() {
+	s.client.Close()
+}
+
+// sameTransaction reports whether the signed transaction has the same contents as the unsigned one
+func sameTransaction(tx, signed *types.Transaction) bool {
+	sameTo := tx.To() == nil && signed.To() == nil ||
+		tx.To() != nil && signed.To() != nil && *tx.To() == *signed.To()
+
+	return sameTo &&
+		tx.Type() == signed.Type() &&
+		tx.Nonce() == signed.Nonce() &&
+		tx.Gas() == signed.Gas() &&
+		tx.Value().Cmp(signed.Value()) == 0 &&
+		tx.GasFeeCap().Cmp(signed.GasFeeCap()) == 0 &&
+		tx.GasTipCap().Cmp(signed.GasTipCap()) == 0 &&
+		string(tx.Data()) == string(signed.Data())
+}
@@ -0,0 +1,158 @@
+package chain
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var testSignerChainID = big.NewInt(11155111)
+
+// testTx returns an unsigned dynamic fee transaction
+func testTx() *types.Transaction {
+	to := common.HexToAddress("0xdAf5794A77d20f773969876ec7AD7b6Ee30727b4")
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   testSignerChainID,
+		Nonce:     7,
+		GasTipCap: big.NewInt(2),
+		GasFeeCap: big.NewInt(200),
+		Gas:       60000,
+		To:        &to,
+		Value:     big.NewInt(0),
+		Data:      []byte{0x12, 0x34},
+	})
+}
+
+// checkSigned checks that the transaction was signed by the address
+func checkSigned(t *testing.T, signed *types.Transaction, address common.Address) {
+	t.Helper()
+
+	sender, err := types.Sender(types.LatestSignerForChainID(testSignerChainID), signed)
+	if err != nil {
+		t.Fatalf("failed to recover sender: %v", err)
+	}
+	if sender != address {
+		t.Errorf("expected sender %s, got %s", address.Hex(), sender.Hex())
+	}
+}
+
+func TestKeystoreSigner(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	dir := t.TempDir()
+	account, err := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP).ImportECDSA(key, "correct horse")
+	if err != nil {
+		t.Fatalf("failed to import key: %v", err)
+	}
+
+	keystoreFile := account.URL.Path
+	passwordFile := filepath.Join(dir, "password")
+	os.WriteFile(passwordFile, []byte("correct horse\n"), 0o600)
+
+	signer, err := NewKeystoreSigner(keystoreFile, passwordFile)
+	if err != nil {
+		t.Fatalf("NewKeystoreSigner() returned error: %v", err)
+	}
+	if signer.Address() != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Errorf("expected address %s, got %s", crypto.PubkeyToAddress(key.PublicKey).Hex(), signer.Address().Hex())
+	}
+
+	signed, err := signer.SignTx(context.Background(), testTx(), testSignerChainID)
+	if err != nil {
+		t.Fatalf("SignTx() returned error: %v", err)
+	}
+	checkSigned(t, signed, signer.Address())
+
+	os.WriteFile(passwordFile, []byte("wrong"), 0o600)
+	if _, err := NewKeystoreSigner(keystoreFile, passwordFile); err == nil {
+		t.Errorf("expected error for a wrong password")
+	}
+}
+
+// fakeSignerService answers eth_signTransaction by signing with its key,
+// optionally changing the gas limit like a misbehaving signer
+type fakeSignerService struct {
+	key       *ecdsa.PrivateKey
+	changeGas bool
+}
+
+func (s *fakeSignerService) SignTransaction(args signTransactionArgs) (*signTransactionResult, error) {
+	gas := uint64(args.Gas)
+	if s.changeGas {
+		gas++
+	}
+
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   args.ChainID.ToInt(),
+		Nonce:     uint64(args.Nonce),
+		GasTipCap: args.MaxPriorityFeePerGas.ToInt(),
+		GasFeeCap: args.MaxFeePerGas.ToInt(),
+		Gas:       gas,
+		To:        args.To,
+		Value:     args.Value.ToInt(),
+		Data:      args.Input,
+	})
+	signed, err := types.SignTx(tx, types.LatestSignerForChainID(args.ChainID.ToInt()), s.key)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return &signTransactionResult{Raw: raw}, nil
+}
+
+// newRemoteSigner starts a remote signer backed by the service and connects to it as address
+func newRemoteSigner(t *testing.T, service *fakeSignerService, address common.Address) *RemoteSigner {
+	t.Helper()
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", service); err != nil {
+		t.Fatalf("failed to register signer service: %v", err)
+	}
+	srv := httptest.NewServer(server)
+	t.Cleanup(srv.Close)
+
+	signer, err := NewRemoteSigner(context.Background(), srv.URL, address)
+	if err != nil {
+		t.Fatalf("NewRemoteSigner() returned error: %v", err)
+	}
+	t.Cleanup(signer.Close)
+	return signer
+}
+
+func TestRemoteSigner(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	address := crypto.PubkeyToAddress(key.PublicKey)
+
+	signed, err := newRemoteSigner(t, &fakeSignerService{key: key}, address).SignTx(context.Background(), testTx(), testSignerChainID)
+	if err != nil {
+		t.Fatalf("SignTx() returned error: %v", err)
+	}
+	checkSigned(t, signed, address)
+	if signed.Nonce() != 7 || signed.Gas() != 60000 {
+		t.Errorf("expected the requested transaction, got nonce %d and gas %d", signed.Nonce(), signed.Gas())
+	}
+
+	otherKey, _ := crypto.GenerateKey()
+	_, err = newRemoteSigner(t, &fakeSignerService{key: otherKey}, address).SignTx(context.Background(), testTx(), testSignerChainID)
+	if err == nil || !strings.Contains(err.Error(), "instead of") {
+		t.Errorf("expected error for a signature of another account, got %v", err)
+	}
+
+	_, err = newRemoteSigner(t, &fakeSignerService{key: key, changeGas: true}, address).SignTx(context.Background(), testTx(), testSignerChainID)
+	if err == nil || !strings.Contains(err.Error(), "different transaction") {
+		t.Errorf("expected error for a changed transaction, got %v", err)
+	}
+}
@@ -87,7 +87,17 @@ func New() Service {
 	err = db.AutoMigrate(
 		&models.User{},
 		&models.Transaction{},
+		&models.TransactionLog{},
 		&models.UserTransaction{},
+		&models.ContractABI{},
+		&models.RefreshToken{},
+		&models.RevokedToken{},
+		&models.APIKey{},
+		&models.Wallet{},
+		&models.SIWENonce{},
+		&models.LoginAttempt{},
+		&models.OutgoingTransaction{},
+		&models.AccountNonce{},
 	)
 	if err != nil {
 		log.Printf("Failed to auto-migrate database: %v", err)
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// AccountNonce is the next nonce the server will use for one of its signing accounts
+type AccountNonce struct {
+	Address   string    `json:"address" gorm:"primaryKey"`
+	NextNonce uint64    `json:"nextNonce" gorm:"not null;default:0"`
+	UpdatedAt time.Time `json:"updatedAt" gorm:"autoUpdateTime"`
+}
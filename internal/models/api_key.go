@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// APIKey lets machine clients authenticate as a user without a password.
+// Only the hash of the key is stored; Prefix identifies the key in listings.
+type APIKey struct {
+	ID         int        `json:"id" gorm:"primaryKey"`
+	UserID     int        `json:"-" gorm:"not null;index"`
+	Name       string     `json:"name" gorm:"not null"`
+	Prefix     string     `json:"prefix" gorm:"not null"`
+	KeyHash    string     `json:"-" gorm:"unique;not null"`
+	Scopes     []string   `json:"scopes" gorm:"serializer:json;not null"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// Active reports whether the key can still be used
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
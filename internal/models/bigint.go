@@ -0,0 +1,71 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// BigInt is an arbitrary-precision integer, used for wei amounts.
+// It is stored as a Postgres numeric and serialized as a decimal string in JSON.
+type BigInt struct {
+	big.Int
+}
+
+// NewBigInt returns a BigInt holding a copy of x, or zero if x is nil
+func NewBigInt(x *big.Int) BigInt {
+	var b BigInt
+	if x != nil {
+		b.Set(x)
+	}
+	return b
+}
+
+// GormDataType stores the value as an arbitrary-precision numeric
+func (BigInt) GormDataType() string {
+	return "numeric"
+}
+
+// Value implements driver.Valuer
+func (b BigInt) Value() (driver.Value, error) {
+	return b.String(), nil
+}
+
+// Scan implements sql.Scanner
+func (b *BigInt) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		b.SetInt64(0)
+	case int64:
+		b.SetInt64(v)
+	case string:
+		return b.setString(v)
+	case []byte:
+		return b.setString(string(v))
+	default:
+		return fmt.Errorf("cannot scan %T into BigInt", src)
+	}
+	return nil
+}
+
+// MarshalJSON encodes the value as a decimal string so clients don't lose precision
+func (b BigInt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.String())
+}
+
+// UnmarshalJSON accepts both decimal strings and JSON numbers
+func (b *BigInt) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		s = string(data)
+	}
+	return b.setString(s)
+}
+
+func (b *BigInt) setString(s string) error {
+	if _, ok := b.SetString(s, 10); !ok {
+		return fmt.Errorf("invalid integer %q", s)
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// ContractABI is the JSON ABI registered for a contract address,
+// used to decode the input and logs of its transactions
+type ContractABI struct {
+	ID        int       `json:"id" gorm:"primaryKey"`
+	Address   string    `json:"address" gorm:"unique;not null"`
+	Name      string    `json:"name"`
+	ABI       string    `json:"abi" gorm:"type:text;not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Reasons a login attempt was rejected
+const (
+	LoginFailureInvalidCredentials = "invalid_credentials"
+	LoginFailureLockedOut          = "locked_out"
+)
+
+// LoginAttempt is an audit record of a failed login
+type LoginAttempt struct {
+	ID        int       `json:"id" gorm:"primaryKey"`
+	Username  string    `json:"username" gorm:"not null;index"`
+	IP        string    `json:"ip" gorm:"not null;index"`
+	Reason    string    `json:"reason" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime;index"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// Outgoing transaction statuses, from submission to inclusion in the chain
+const (
+	OutgoingStatusQueued    = "queued"
+	OutgoingStatusBroadcast = "broadcast"
+	OutgoingStatusMined     = "mined"
+	OutgoingStatusFailed    = "failed"
+	OutgoingStatusReplaced  = "replaced"
+	// OutgoingStatusCancelling is a transaction being replaced by a 0-value transfer to the sender
+	OutgoingStatusCancelling = "cancelling"
+	OutgoingStatusCancelled  = "cancelled"
+)
+
+// OutgoingTransaction is a transaction sent by the server, e.g. to save a person in the contract.
+// Its ID is the job ID clients poll the status with.
+type OutgoingTransaction struct {
+	ID              int    `json:"id" gorm:"primaryKey"`
+	UserID          int    `json:"userId" gorm:"not null;index"`
+	Kind            string `json:"kind" gorm:"not null"`
+	Status          string `json:"status" gorm:"not null;index"`
+	TransactionHash string `json:"transactionHash" gorm:"index"`
+	// PreviousHashes are the hashes of the transactions this one replaced to speed it up or cancel it
+	PreviousHashes       []string   `json:"previousHashes" gorm:"serializer:json"`
+	From                 string     `json:"from"`
+	To                   string     `json:"to" gorm:"not null"`
+	Nonce                uint64     `json:"nonce" gorm:"not null;default:0"`
+	Input                string     `json:"input" gorm:"type:text;not null"`
+	GasLimit             uint64     `json:"gasLimit" gorm:"not null;default:0"`
+	GasPrice             BigInt     `json:"gasPrice"`
+	FeePolicy            string     `json:"feePolicy"`
+	MaxFeePerGas         BigInt     `json:"maxFeePerGas" gorm:"not null;default:0"`
+	MaxPriorityFeePerGas BigInt     `json:"maxPriorityFeePerGas" gorm:"not null;default:0"`
+	BlockNumber          uint64     `json:"blockNumber" gorm:"not null;default:0"`
+	Error                string     `json:"error,omitempty"`
+	BroadcastAt          *time.Time `json:"broadcastAt"`
+	MinedAt              *time.Time `json:"minedAt"`
+	CreatedAt            time.Time  `json:"createdAt" gorm:"autoCreateTime"`
+	UpdatedAt            time.Time  `json:"updatedAt" gorm:"autoUpdateTime"`
+}
+
+// Outgoing transaction kinds
+const (
+	// OutgoingKindSavePerson is the kind of transactions calling setPersonInfo on the contract
+	OutgoingKindSavePerson = "savePerson"
+	// OutgoingKindFillNonce is the kind of 0-value transfers to the sender filling a nonce
+	// left unused by a failed transaction, so later transactions can be mined
+	OutgoingKindFillNonce = "fillNonce"
+)
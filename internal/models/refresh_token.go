@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// RefreshToken is a long-lived token exchanged for new access tokens.
+// Only the hash of the token is stored; each token can be used once and is
+// replaced by a new one on refresh.
+type RefreshToken struct {
+	ID         int        `json:"id" gorm:"primaryKey"`
+	UserID     int        `json:"user_id" gorm:"not null;index"`
+	TokenHash  string     `json:"-" gorm:"unique;not null"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"not null;index"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	ReplacedBy *int       `json:"replaced_by"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// Active reports whether the token can still be used
+func (t *RefreshToken) Active(now time.Time) bool {
+	return t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
+
+// RevokedToken is an access token revoked before its expiry, identified by its token ID.
+// Entries can be removed once the token would have expired anyway.
+type RevokedToken struct {
+	TokenID   string    `json:"token_id" gorm:"primaryKey"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
@@ -2,18 +2,51 @@ package models
 
 import "time"
 
+// Transaction statuses tracking a transaction's inclusion in the chain
+const (
+	TransactionStatusPending  = "pending"
+	TransactionStatusMined    = "mined"
+	TransactionStatusDropped  = "dropped"
+	TransactionStatusReplaced = "replaced"
+)
+
 type Transaction struct {
-	ID                int       `json:"id" gorm:"primaryKey"`
-	TransactionHash   string    `json:"transactionHash" gorm:"unique;not null"`
-	TransactionStatus int       `json:"transactionStatus"`
-	BlockHash         string    `json:"blockHash"`
-	BlockNumber       int       `json:"blockNumber"`
-	From              string    `json:"from"`
-	To                string    `json:"to"`
-	ContractAddress   string    `json:"contractAddress"`
-	LogsCount         int       `json:"logsCount"`
-	Input             string    `json:"input"`
-	Value             int       `json:"value"`
-	Users             []User    `json:"users" gorm:"many2many:user_transactions;"`
-	CreatedAt         time.Time `json:"created_at" gorm:"autoCreateTime"`
+	ID                int    `json:"id" gorm:"primaryKey"`
+	TransactionHash   string `json:"transactionHash" gorm:"unique;not null"`
+	Status            string `json:"status" gorm:"index;not null;default:mined"`
+	TransactionStatus int    `json:"transactionStatus"`
+	BlockHash         string `json:"blockHash"`
+	BlockNumber       uint64 `json:"blockNumber"`
+	From              string `json:"from"`
+	To                string `json:"to"`
+	Nonce             uint64 `json:"nonce" gorm:"not null;default:0"`
+	ContractAddress   string `json:"contractAddress"`
+	LogsCount         int    `json:"logsCount"`
+	Input             string `json:"input"`
+	Value             BigInt `json:"value"`
+
+	// Transaction fields
+	Type                 int      `json:"type" gorm:"not null;default:0"`
+	ChainID              uint64   `json:"chainId" gorm:"not null;default:0"`
+	Gas                  uint64   `json:"gas" gorm:"not null;default:0"`
+	GasPrice             BigInt   `json:"gasPrice" gorm:"not null;default:0"`
+	MaxFeePerGas         BigInt   `json:"maxFeePerGas" gorm:"not null;default:0"`
+	MaxPriorityFeePerGas BigInt   `json:"maxPriorityFeePerGas" gorm:"not null;default:0"`
+	BlobGas              uint64   `json:"blobGas" gorm:"not null;default:0"`
+	MaxFeePerBlobGas     BigInt   `json:"maxFeePerBlobGas" gorm:"not null;default:0"`
+	BlobVersionedHashes  []string `json:"blobVersionedHashes" gorm:"serializer:json"`
+
+	// Receipt fields, set once the transaction is mined
+	TransactionIndex  uint   `json:"transactionIndex" gorm:"not null;default:0"`
+	GasUsed           uint64 `json:"gasUsed" gorm:"not null;default:0"`
+	CumulativeGasUsed uint64 `json:"cumulativeGasUsed" gorm:"not null;default:0"`
+	EffectiveGasPrice BigInt `json:"effectiveGasPrice" gorm:"not null;default:0"`
+	BlobGasUsed       uint64 `json:"blobGasUsed" gorm:"not null;default:0"`
+	BlobGasPrice      BigInt `json:"blobGasPrice" gorm:"not null;default:0"`
+	// Fee is the total amount paid for the transaction, including blob gas
+	Fee BigInt `json:"fee" gorm:"not null;default:0"`
+
+	Logs      []TransactionLog `json:"-" gorm:"foreignKey:TransactionHash;references:TransactionHash"`
+	Users     []User           `json:"users" gorm:"many2many:user_transactions;"`
+	CreatedAt time.Time        `json:"created_at" gorm:"autoCreateTime"`
 }
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// TransactionLog is a log emitted by a transaction, as found in its receipt
+type TransactionLog struct {
+	ID              int    `json:"id" gorm:"primaryKey"`
+	TransactionHash string `json:"transactionHash" gorm:"not null;uniqueIndex:idx_transaction_log"`
+	LogIndex        uint   `json:"logIndex" gorm:"not null;uniqueIndex:idx_transaction_log"`
+	Address         string `json:"address" gorm:"not null;index"`
+	// Topic0 duplicates the first topic, usually the event signature, so logs can be filtered by it
+	Topic0      string    `json:"-" gorm:"index"`
+	Topics      []string  `json:"topics" gorm:"serializer:json"`
+	Data        string    `json:"data"`
+	BlockNumber uint64    `json:"blockNumber"`
+	Removed     bool      `json:"removed"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
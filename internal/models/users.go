@@ -5,7 +5,9 @@ import "time"
 type User struct {
 	ID           int           `json:"id" gorm:"primaryKey"`
 	Username     string        `json:"username" gorm:"unique;not null"`
-	Password     string        `json:"password" gorm:"not null"`
+	DisplayName  string        `json:"display_name" gorm:"not null;default:''"`
+	Password     string        `json:"-" gorm:"not null"`
+	Roles        []string      `json:"roles" gorm:"serializer:json;not null;default:'[\"viewer\"]'"`
 	CreatedAt    time.Time     `json:"created_at" gorm:"not null"`
 	Transactions []Transaction `json:"transactions" gorm:"many2many:user_transactions;"`
 }
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Wallet is an Ethereum address a user proved control of with Sign-In with Ethereum
+type Wallet struct {
+	ID        int       `json:"id" gorm:"primaryKey"`
+	UserID    int       `json:"-" gorm:"not null;index"`
+	User      *User     `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Address   string    `json:"address" gorm:"unique;not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// SIWENonce is a nonce issued for a Sign-In with Ethereum message. Each nonce can be used once.
+type SIWENonce struct {
+	Nonce     string     `json:"nonce" gorm:"primaryKey"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null;index"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"ethereum-fetcher-go/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// accountNonceRepository implements AccountNonceRepository interface
+type accountNonceRepository struct {
+	*BaseRepository
+}
+
+// NewAccountNonceRepository creates a new account nonce repository instance
+func NewAccountNonceRepository(db *gorm.DB) AccountNonceRepository {
+	return &accountNonceRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// GetByAddress retrieves the next nonce of an account by its checksummed address
+func (r *accountNonceRepository) GetByAddress(ctx context.Context, address string) (*models.AccountNonce, error) {
+	var nonce models.AccountNonce
+	err := r.DB.WithContext(ctx).Where("address = ?", address).First(&nonce).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &nonce, nil
+}
+
+// Upsert stores the next nonce of an account
+func (r *accountNonceRepository) Upsert(ctx context.Context, nonce *models.AccountNonce) error {
+	return r.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "address"}},
+		DoUpdates: clause.AssignmentColumns([]string{"next_nonce", "updated_at"}),
+	}).Create(nonce).Error
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ethereum-fetcher-go/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// apiKeyRepository implements APIKeyRepository interface
+type apiKeyRepository struct {
+	*BaseRepository
+}
+
+// NewAPIKeyRepository creates a new API key repository instance
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// Create stores a new API key
+func (r *apiKeyRepository) Create(ctx context.Context, apiKey *models.APIKey) (*models.APIKey, error) {
+	if err := r.DB.WithContext(ctx).Create(apiKey).Error; err != nil {
+		return nil, err
+	}
+	return apiKey, nil
+}
+
+// GetByHash retrieves an API key by the hash of its value
+func (r *apiKeyRepository) GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	var apiKey models.APIKey
+	err := r.DB.WithContext(ctx).Where("key_hash = ?", keyHash).First(&apiKey).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &apiKey, nil
+}
+
+// GetByUserID retrieves the API keys of a user, newest first
+func (r *apiKeyRepository) GetByUserID(ctx context.Context, userID int) ([]*models.APIKey, error) {
+	var apiKeys []*models.APIKey
+	if err := r.DB.WithContext(ctx).Where("user_id = ?", userID).Order("id DESC").Find(&apiKeys).Error; err != nil {
+		return nil, err
+	}
+	return apiKeys, nil
+}
+
+// Revoke revokes an active API key of the user
+func (r *apiKeyRepository) Revoke(ctx context.Context, userID int, id int) (bool, error) {
+	result := r.DB.WithContext(ctx).Model(&models.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+// UpdateLastUsed records when an API key was last used
+func (r *apiKeyRepository) UpdateLastUsed(ctx context.Context, id int, usedAt time.Time) error {
+	return r.DB.WithContext(ctx).Model(&models.APIKey{}).Where("id = ?", id).Update("last_used_at", usedAt).Error
+}
+
+// CountActive counts the API keys of a user that are neither revoked nor expired
+func (r *apiKeyRepository) CountActive(ctx context.Context, userID int, now time.Time) (int64, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).Model(&models.APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", userID, now).
+		Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"ethereum-fetcher-go/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// contractABIRepository implements ContractABIRepository interface
+type contractABIRepository struct {
+	*BaseRepository
+}
+
+// NewContractABIRepository creates a new contract ABI repository instance
+func NewContractABIRepository(db *gorm.DB) ContractABIRepository {
+	return &contractABIRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// Upsert stores the ABI of a contract, replacing any ABI previously registered for its address
+func (r *contractABIRepository) Upsert(ctx context.Context, contractABI *models.ContractABI) (*models.ContractABI, error) {
+	err := r.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "address"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "abi", "updated_at"}),
+	}).Create(contractABI).Error
+	if err != nil {
+		return nil, err
+	}
+	return contractABI, nil
+}
+
+// GetByAddress retrieves the ABI registered for a contract address
+func (r *contractABIRepository) GetByAddress(ctx context.Context, address string) (*models.ContractABI, error) {
+	var contractABI models.ContractABI
+	err := r.DB.WithContext(ctx).Where("address = ?", address).First(&contractABI).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &contractABI, nil
+}
+
+// GetAll retrieves all registered ABIs
+func (r *contractABIRepository) GetAll(ctx context.Context) ([]*models.ContractABI, error) {
+	var contractABIs []*models.ContractABI
+	if err := r.DB.WithContext(ctx).Order("address").Find(&contractABIs).Error; err != nil {
+		return nil, err
+	}
+	return contractABIs, nil
+}
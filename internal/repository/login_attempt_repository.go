@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+
+	"ethereum-fetcher-go/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// loginAttemptRepository implements LoginAttemptRepository interface
+type loginAttemptRepository struct {
+	*BaseRepository
+}
+
+// NewLoginAttemptRepository creates a new login attempt repository instance
+func NewLoginAttemptRepository(db *gorm.DB) LoginAttemptRepository {
+	return &loginAttemptRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// Create records a failed login attempt
+func (r *loginAttemptRepository) Create(ctx context.Context, attempt *models.LoginAttempt) error {
+	return r.DB.WithContext(ctx).Create(attempt).Error
+}
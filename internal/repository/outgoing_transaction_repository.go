@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"ethereum-fetcher-go/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// outgoingTransactionRepository implements OutgoingTransactionRepository interface
+type outgoingTransactionRepository struct {
+	*BaseRepository
+}
+
+// NewOutgoingTransactionRepository creates a new outgoing transaction repository instance
+func NewOutgoingTransactionRepository(db *gorm.DB) OutgoingTransactionRepository {
+	return &outgoingTransactionRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// Create stores a new outgoing transaction
+func (r *outgoingTransactionRepository) Create(ctx context.Context, tx *models.OutgoingTransaction) (*models.OutgoingTransaction, error) {
+	if err := r.DB.WithContext(ctx).Create(tx).Error; err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// Update saves all fields of an existing outgoing transaction
+func (r *outgoingTransactionRepository) Update(ctx context.Context, tx *models.OutgoingTransaction) error {
+	return r.DB.WithContext(ctx).Save(tx).Error
+}
+
+// GetByID retrieves an outgoing transaction by its job ID
+func (r *outgoingTransactionRepository) GetByID(ctx context.Context, id int) (*models.OutgoingTransaction, error) {
+	var tx models.OutgoingTransaction
+	err := r.DB.WithContext(ctx).First(&tx, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// GetByHash retrieves an outgoing transaction by its current or a previous transaction hash
+func (r *outgoingTransactionRepository) GetByHash(ctx context.Context, hash string) (*models.OutgoingTransaction, error) {
+	var tx models.OutgoingTransaction
+	err := r.DB.WithContext(ctx).
+		Where("LOWER(transaction_hash) = LOWER(?)", hash).
+		Or("LOWER(previous_hashes) LIKE LOWER(?)", `%"`+hash+`"%`).
+		First(&tx).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// GetByStatus retrieves all outgoing transactions with the given status, oldest first
+func (r *outgoingTransactionRepository) GetByStatus(ctx context.Context, status string) ([]*models.OutgoingTransaction, error) {
+	var txs []*models.OutgoingTransaction
+	if err := r.DB.WithContext(ctx).Where("status = ?", status).Order("id").Find(&txs).Error; err != nil {
+		return nil, err
+	}
+	return txs, nil
+}
+
+// HasPendingFrom reports whether the account has transactions that may still be pending
+// with a nonce at or above the given one. Queued jobs count once they were signed.
+func (r *outgoingTransactionRepository) HasPendingFrom(ctx context.Context, from string, nonce uint64) (bool, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).Model(&models.OutgoingTransaction{}).
+		Where(`LOWER("from") = LOWER(?) AND nonce >= ?`, from, nonce).
+		Where("status IN ? OR (status = ? AND transaction_hash <> '')",
+			[]string{models.OutgoingStatusBroadcast, models.OutgoingStatusCancelling}, models.OutgoingStatusQueued).
+		Count(&count).Error
+	return count > 0, err
+}
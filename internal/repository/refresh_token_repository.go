@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ethereum-fetcher-go/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// errTokenAlreadyRevoked rolls back a rotation of a token that was used already
+var errTokenAlreadyRevoked = errors.New("refresh token already revoked")
+
+// refreshTokenRepository implements RefreshTokenRepository interface
+type refreshTokenRepository struct {
+	*BaseRepository
+}
+
+// NewRefreshTokenRepository creates a new refresh token repository instance
+func NewRefreshTokenRepository(db *gorm.DB) RefreshTokenRepository {
+	return &refreshTokenRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// Create stores a new refresh token
+func (r *refreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) (*models.RefreshToken, error) {
+	if err := r.DB.WithContext(ctx).Create(token).Error; err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// GetByHash retrieves a refresh token by the hash of its value
+func (r *refreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.DB.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Rotate revokes the token and stores its replacement in a single transaction.
+// The revocation is conditional, so concurrent refreshes with the same token can't both succeed.
+func (r *refreshTokenRepository) Rotate(ctx context.Context, token *models.RefreshToken, replacement *models.RefreshToken) (bool, error) {
+	rotated := false
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(replacement).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", token.ID).
+			Updates(map[string]any{"revoked_at": time.Now(), "replaced_by": replacement.ID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			// Roll back the replacement, the token was used already
+			return errTokenAlreadyRevoked
+		}
+
+		rotated = true
+		return nil
+	})
+	if errors.Is(err, errTokenAlreadyRevoked) {
+		return false, nil
+	}
+	return rotated, err
+}
+
+// Revoke revokes a single refresh token
+func (r *refreshTokenRepository) Revoke(ctx context.Context, id int) error {
+	return r.DB.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeAllForUser revokes every active refresh token of a user
+func (r *refreshTokenRepository) RevokeAllForUser(ctx context.Context, userID int) error {
+	return r.DB.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// DeleteExpired removes refresh tokens that expired before the given time
+func (r *refreshTokenRepository) DeleteExpired(ctx context.Context, before time.Time) error {
+	return r.DB.WithContext(ctx).Where("expires_at < ?", before).Delete(&models.RefreshToken{}).Error
+}
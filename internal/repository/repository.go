@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"ethereum-fetcher-go/internal/models"
 
@@ -39,6 +40,11 @@ type UserRepository interface {
 	Create(ctx context.Context, user *models.User) (*models.User, error)
 	GetByID(ctx context.Context, id int) (*models.User, error)
 	GetByUsername(ctx context.Context, username string) (*models.User, error)
+	UpdatePassword(ctx context.Context, id int, passwordHash string) error
+	UpdateRoles(ctx context.Context, id int, roles []string) error
+	UpdateProfile(ctx context.Context, id int, username, displayName string) error
+	// Delete deletes the user with their transaction history, tokens, API keys and wallets
+	Delete(ctx context.Context, id int) error
 }
 
 // TransactionRepository defines the interface for transaction-related operations
@@ -46,9 +52,108 @@ type TransactionRepository interface {
 	Repository
 	Create(ctx context.Context, tx *models.Transaction) (*models.Transaction, error)
 	GetByID(ctx context.Context, id int) (*models.Transaction, error)
-	GetAll(ctx context.Context) ([]*models.Transaction, error)
+	GetAll(ctx context.Context, limit, offset int) ([]*models.Transaction, int64, error)
 	GetByHash(ctx context.Context, hash string) (*models.Transaction, error)
 	GetByHashes(ctx context.Context, hashes []string) ([]*models.Transaction, error)
+	GetByStatus(ctx context.Context, status string) ([]*models.Transaction, error)
+	Update(ctx context.Context, tx *models.Transaction) error
+}
+
+// LogFilter narrows down the logs returned by a TransactionLogRepository.
+// Empty fields are ignored.
+type LogFilter struct {
+	Address string
+	Topic0  string
+}
+
+// TransactionLogRepository defines the interface for transaction log operations
+type TransactionLogRepository interface {
+	Repository
+	GetByTransactionHash(ctx context.Context, transactionHash string, filter LogFilter) ([]*models.TransactionLog, error)
+	GetByTransactionHashes(ctx context.Context, transactionHashes []string) ([]*models.TransactionLog, error)
+}
+
+// ContractABIRepository defines the interface for contract ABI operations
+type ContractABIRepository interface {
+	Repository
+	Upsert(ctx context.Context, contractABI *models.ContractABI) (*models.ContractABI, error)
+	GetByAddress(ctx context.Context, address string) (*models.ContractABI, error)
+	GetAll(ctx context.Context) ([]*models.ContractABI, error)
+}
+
+// RefreshTokenRepository defines the interface for refresh token operations
+type RefreshTokenRepository interface {
+	Repository
+	Create(ctx context.Context, token *models.RefreshToken) (*models.RefreshToken, error)
+	GetByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	// Rotate revokes the token and stores its replacement. It returns false without
+	// storing the replacement if the token was already revoked.
+	Rotate(ctx context.Context, token *models.RefreshToken, replacement *models.RefreshToken) (bool, error)
+	Revoke(ctx context.Context, id int) error
+	RevokeAllForUser(ctx context.Context, userID int) error
+	DeleteExpired(ctx context.Context, before time.Time) error
+}
+
+// RevokedTokenRepository defines the interface for the access token revocation list
+type RevokedTokenRepository interface {
+	Repository
+	Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, tokenID string) (bool, error)
+	DeleteExpired(ctx context.Context, before time.Time) error
+}
+
+// APIKeyRepository defines the interface for API key operations
+type APIKeyRepository interface {
+	Repository
+	Create(ctx context.Context, apiKey *models.APIKey) (*models.APIKey, error)
+	GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+	GetByUserID(ctx context.Context, userID int) ([]*models.APIKey, error)
+	// Revoke revokes a key of the user. It returns false if the user has no such active key.
+	Revoke(ctx context.Context, userID int, id int) (bool, error)
+	UpdateLastUsed(ctx context.Context, id int, usedAt time.Time) error
+	CountActive(ctx context.Context, userID int, now time.Time) (int64, error)
+}
+
+// WalletRepository defines the interface for wallet operations
+type WalletRepository interface {
+	Repository
+	// Create stores the wallet, creating its User in the same transaction if it has no ID yet
+	Create(ctx context.Context, wallet *models.Wallet) (*models.Wallet, error)
+	GetByAddress(ctx context.Context, address string) (*models.Wallet, error)
+	GetByUserID(ctx context.Context, userID int) ([]*models.Wallet, error)
+}
+
+// SIWENonceRepository defines the interface for Sign-In with Ethereum nonce operations
+type SIWENonceRepository interface {
+	Repository
+	Create(ctx context.Context, nonce *models.SIWENonce) (*models.SIWENonce, error)
+	// Consume marks an unexpired nonce as used. It returns false if the nonce is unknown, expired or used.
+	Consume(ctx context.Context, nonce string, now time.Time) (bool, error)
+	DeleteExpired(ctx context.Context, before time.Time) error
+}
+
+// LoginAttemptRepository defines the interface for the audit log of failed logins
+type LoginAttemptRepository interface {
+	Repository
+	Create(ctx context.Context, attempt *models.LoginAttempt) error
+}
+
+// OutgoingTransactionRepository defines the interface for transactions sent by the server
+type OutgoingTransactionRepository interface {
+	Repository
+	Create(ctx context.Context, tx *models.OutgoingTransaction) (*models.OutgoingTransaction, error)
+	Update(ctx context.Context, tx *models.OutgoingTransaction) error
+	GetByID(ctx context.Context, id int) (*models.OutgoingTransaction, error)
+	GetByHash(ctx context.Context, hash string) (*models.OutgoingTransaction, error)
+	GetByStatus(ctx context.Context, status string) ([]*models.OutgoingTransaction, error)
+	HasPendingFrom(ctx context.Context, from string, nonce uint64) (bool, error)
+}
+
+// AccountNonceRepository defines the interface for the persisted nonces of the server's signing accounts
+type AccountNonceRepository interface {
+	Repository
+	GetByAddress(ctx context.Context, address string) (*models.AccountNonce, error)
+	Upsert(ctx context.Context, nonce *models.AccountNonce) error
 }
 
 type UserTransactionRepository interface {
@@ -56,4 +161,16 @@ type UserTransactionRepository interface {
 	Create(ctx context.Context, userID int, transactionHash string) (*models.UserTransaction, error)
 	GetByTransactionHashAndUserId(ctx context.Context, transactionHash string, userID int) (*models.UserTransaction, error)
 	GetTransactionsByUserId(ctx context.Context, userID int) ([]*models.UserTransaction, error)
+	// GetHistory returns a page of the lookups of a user, newest first, and the total number of matching lookups
+	GetHistory(ctx context.Context, userID int, filter HistoryFilter) ([]*models.UserTransaction, int64, error)
+}
+
+// HistoryFilter narrows down and paginates the lookup history of a user.
+// Zero values are ignored, except for Limit.
+type HistoryFilter struct {
+	Since  time.Time
+	Until  time.Time
+	Status string
+	Limit  int
+	Offset int
 }
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"ethereum-fetcher-go/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// revokedTokenRepository implements RevokedTokenRepository interface
+type revokedTokenRepository struct {
+	*BaseRepository
+}
+
+// NewRevokedTokenRepository creates a new revoked token repository instance
+func NewRevokedTokenRepository(db *gorm.DB) RevokedTokenRepository {
+	return &revokedTokenRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// Revoke adds an access token to the revocation list
+func (r *revokedTokenRepository) Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	return r.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&models.RevokedToken{
+		TokenID:   tokenID,
+		ExpiresAt: expiresAt,
+	}).Error
+}
+
+// IsRevoked reports whether an access token was revoked
+func (r *revokedTokenRepository) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).Model(&models.RevokedToken{}).Where("token_id = ?", tokenID).Count(&count).Error
+	return count > 0, err
+}
+
+// DeleteExpired removes revoked tokens that expired before the given time
+func (r *revokedTokenRepository) DeleteExpired(ctx context.Context, before time.Time) error {
+	return r.DB.WithContext(ctx).Where("expires_at < ?", before).Delete(&models.RevokedToken{}).Error
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"ethereum-fetcher-go/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// siweNonceRepository implements SIWENonceRepository interface
+type siweNonceRepository struct {
+	*BaseRepository
+}
+
+// NewSIWENonceRepository creates a new Sign-In with Ethereum nonce repository instance
+func NewSIWENonceRepository(db *gorm.DB) SIWENonceRepository {
+	return &siweNonceRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// Create stores a newly issued nonce
+func (r *siweNonceRepository) Create(ctx context.Context, nonce *models.SIWENonce) (*models.SIWENonce, error) {
+	if err := r.DB.WithContext(ctx).Create(nonce).Error; err != nil {
+		return nil, err
+	}
+	return nonce, nil
+}
+
+// Consume marks a nonce as used. The update is conditional, so a nonce can't be used twice concurrently.
+func (r *siweNonceRepository) Consume(ctx context.Context, nonce string, now time.Time) (bool, error) {
+	result := r.DB.WithContext(ctx).Model(&models.SIWENonce{}).
+		Where("nonce = ? AND used_at IS NULL AND expires_at > ?", nonce, now).
+		Update("used_at", now)
+	return result.RowsAffected == 1, result.Error
+}
+
+// DeleteExpired removes nonces that expired before the given time
+func (r *siweNonceRepository) DeleteExpired(ctx context.Context, before time.Time) error {
+	return r.DB.WithContext(ctx).Where("expires_at < ?", before).Delete(&models.SIWENonce{}).Error
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+
+	"ethereum-fetcher-go/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// transactionLogRepository implements TransactionLogRepository interface
+type transactionLogRepository struct {
+	*BaseRepository
+}
+
+// NewTransactionLogRepository creates a new transaction log repository instance
+func NewTransactionLogRepository(db *gorm.DB) TransactionLogRepository {
+	return &transactionLogRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// GetByTransactionHash retrieves the logs of a transaction ordered by log index
+func (r *transactionLogRepository) GetByTransactionHash(ctx context.Context, transactionHash string, filter LogFilter) ([]*models.TransactionLog, error) {
+	query := r.DB.WithContext(ctx).Where("transaction_hash = ?", transactionHash)
+
+	if filter.Address != "" {
+		query = query.Where("address = ?", filter.Address)
+	}
+	if filter.Topic0 != "" {
+		query = query.Where("topic0 = ?", filter.Topic0)
+	}
+
+	var logs []*models.TransactionLog
+	if err := query.Order("log_index").Find(&logs).Error; err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+// GetByTransactionHashes retrieves the logs of several transactions ordered by log index
+func (r *transactionLogRepository) GetByTransactionHashes(ctx context.Context, transactionHashes []string) ([]*models.TransactionLog, error) {
+	var logs []*models.TransactionLog
+	err := r.DB.WithContext(ctx).
+		Where("transaction_hash IN ?", transactionHashes).
+		Order("log_index").
+		Find(&logs).Error
+	if err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
@@ -7,6 +7,7 @@ import (
 	"ethereum-fetcher-go/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // transactionRepository implements TransactionRepository interface
@@ -21,11 +22,17 @@ func NewTransactionRepository(db *gorm.DB) TransactionRepository {
 	}
 }
 
-// Create creates a new transaction
+// Create creates a new transaction. If the transaction was already stored, e.g. by a
+// concurrent request fetching the same hash, the stored transaction is returned instead.
 func (r *transactionRepository) Create(ctx context.Context, tx *models.Transaction) (*models.Transaction, error) {
-	err := r.DB.WithContext(ctx).Create(tx).Error
-	if err != nil {
-		return nil, err
+	result := r.DB.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "transaction_hash"}}, DoNothing: true}).
+		Create(tx)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return r.GetByHash(ctx, tx.TransactionHash)
 	}
 	return tx, nil
 }
@@ -65,13 +72,32 @@ func (r *transactionRepository) GetByHashes(ctx context.Context, hashes []string
 	return txs, nil
 }
 
-// GetAll retrieves all transactions
-func (r *transactionRepository) GetAll(ctx context.Context) ([]*models.Transaction, error) {
+// GetByStatus retrieves all transactions with the given status
+func (r *transactionRepository) GetByStatus(ctx context.Context, status string) ([]*models.Transaction, error) {
 	var txs []*models.Transaction
-	err := r.DB.WithContext(ctx).Find(&txs).Error
-
+	err := r.DB.WithContext(ctx).Where("status = ?", status).Find(&txs).Error
 	if err != nil {
 		return nil, err
 	}
 	return txs, nil
 }
+
+// Update saves all fields of an existing transaction
+func (r *transactionRepository) Update(ctx context.Context, tx *models.Transaction) error {
+	return r.DB.WithContext(ctx).Save(tx).Error
+}
+
+// GetAll retrieves a page of all transactions, oldest first, and the total number of transactions
+func (r *transactionRepository) GetAll(ctx context.Context, limit, offset int) ([]*models.Transaction, int64, error) {
+	var total int64
+	if err := r.DB.WithContext(ctx).Model(&models.Transaction{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var txs []*models.Transaction
+	err := r.DB.WithContext(ctx).Order("id").Limit(limit).Offset(offset).Find(&txs).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return txs, total, nil
+}
@@ -54,3 +54,37 @@ func (r *userRepository) GetByUsername(ctx context.Context, username string) (*m
 	}
 	return &user, nil
 }
+
+// UpdatePassword replaces the stored password hash of a user
+func (r *userRepository) UpdatePassword(ctx context.Context, id int, passwordHash string) error {
+	return r.DB.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Update("password", passwordHash).Error
+}
+
+// UpdateRoles replaces the roles of a user
+func (r *userRepository) UpdateRoles(ctx context.Context, id int, roles []string) error {
+	return r.DB.WithContext(ctx).Model(&models.User{ID: id}).Select("roles").Updates(&models.User{Roles: roles}).Error
+}
+
+// UpdateProfile replaces the username and display name of a user
+func (r *userRepository) UpdateProfile(ctx context.Context, id int, username, displayName string) error {
+	return r.DB.WithContext(ctx).Model(&models.User{ID: id}).
+		Select("username", "display_name").
+		Updates(&models.User{Username: username, DisplayName: displayName}).Error
+}
+
+// Delete deletes a user and the rows referencing them in a single transaction
+func (r *userRepository) Delete(ctx context.Context, id int) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, model := range []any{
+			&models.UserTransaction{},
+			&models.RefreshToken{},
+			&models.APIKey{},
+			&models.Wallet{},
+		} {
+			if err := tx.Where("user_id = ?", id).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&models.User{}, id).Error
+	})
+}
@@ -68,3 +68,41 @@ func (r *userTransactionRepository) GetTransactionsByUserId(ctx context.Context,
 
 	return userTransactions, nil
 }
+
+// GetHistory retrieves a page of the lookups of a user matching the filter
+func (r *userTransactionRepository) GetHistory(ctx context.Context, userID int, filter HistoryFilter) ([]*models.UserTransaction, int64, error) {
+	// The query is built twice, for the count and the page, since gorm statements can't be reused safely
+	matching := func() *gorm.DB {
+		query := r.DB.WithContext(ctx).Model(&models.UserTransaction{}).Where("user_transactions.user_id = ?", userID)
+		if !filter.Since.IsZero() {
+			query = query.Where("user_transactions.created_at >= ?", filter.Since)
+		}
+		if !filter.Until.IsZero() {
+			query = query.Where("user_transactions.created_at < ?", filter.Until)
+		}
+		if filter.Status != "" {
+			// Looked up hashes are stored as requested, so they are matched case-insensitively
+			query = query.
+				Joins("JOIN transactions ON LOWER(transactions.transaction_hash) = LOWER(user_transactions.transaction_hash)").
+				Where("transactions.status = ?", filter.Status)
+		}
+		return query
+	}
+
+	var total int64
+	if err := matching().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var userTransactions []*models.UserTransaction
+	err := matching().
+		Order("user_transactions.created_at DESC, user_transactions.id DESC").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&userTransactions).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return userTransactions, total, nil
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"ethereum-fetcher-go/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// walletRepository implements WalletRepository interface
+type walletRepository struct {
+	*BaseRepository
+}
+
+// NewWalletRepository creates a new wallet repository instance
+func NewWalletRepository(db *gorm.DB) WalletRepository {
+	return &walletRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// Create stores a wallet. A new User set on the wallet is created with it in one transaction.
+func (r *walletRepository) Create(ctx context.Context, wallet *models.Wallet) (*models.Wallet, error) {
+	if err := r.DB.WithContext(ctx).Create(wallet).Error; err != nil {
+		return nil, err
+	}
+	return wallet, nil
+}
+
+// GetByAddress retrieves a wallet by its checksummed address
+func (r *walletRepository) GetByAddress(ctx context.Context, address string) (*models.Wallet, error) {
+	var wallet models.Wallet
+	err := r.DB.WithContext(ctx).Where("address = ?", address).First(&wallet).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &wallet, nil
+}
+
+// GetByUserID retrieves the wallets linked to a user
+func (r *walletRepository) GetByUserID(ctx context.Context, userID int) ([]*models.Wallet, error) {
+	var wallets []*models.Wallet
+	if err := r.DB.WithContext(ctx).Where("user_id = ?", userID).Order("id").Find(&wallets).Error; err != nil {
+		return nil, err
+	}
+	return wallets, nil
+}
@@ -0,0 +1,297 @@
+package server
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	embedded "ethereum-fetcher-go/internal/abi"
+	"ethereum-fetcher-go/internal/contracts"
+	"ethereum-fetcher-go/internal/models"
+	"ethereum-fetcher-go/internal/repository"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+)
+
+// DecodedCall is the decoded input of a contract call
+type DecodedCall struct {
+	Method    string         `json:"method"`
+	Signature string         `json:"signature"`
+	Args      map[string]any `json:"args"`
+}
+
+// DecodedEvent is a decoded transaction log
+type DecodedEvent struct {
+	LogIndex  uint           `json:"logIndex"`
+	Address   string         `json:"address"`
+	Event     string         `json:"event"`
+	Signature string         `json:"signature"`
+	Args      map[string]any `json:"args"`
+}
+
+const (
+	// abiCacheSize bounds the number of contracts whose ABI lookup is cached
+	abiCacheSize = 1024
+
+	// missingABITTL is how long a contract is remembered to have no registered ABI,
+	// so ABIs registered through another server instance are picked up eventually
+	missingABITTL = 5 * time.Minute
+)
+
+// abiCacheEntry is a cached ABI lookup. Entries for contracts without an ABI expire.
+type abiCacheEntry struct {
+	parsed  *abi.ABI
+	expires time.Time
+}
+
+// abiRegistry resolves contract ABIs by address and decodes transaction inputs and logs.
+// Parsed ABIs are cached in a bounded LRU cache; contracts without a registered ABI fall back
+// to the ABIs of the contract bindings, which are matched by method selector or event signature alone.
+type abiRegistry struct {
+	repo     repository.ContractABIRepository
+	fallback []*abi.ABI
+
+	cache *lru.Cache[common.Address, abiCacheEntry]
+}
+
+func newABIRegistry(repo repository.ContractABIRepository) *abiRegistry {
+	registry := &abiRegistry{
+		repo:  repo,
+		cache: lru.NewCache[common.Address, abiCacheEntry](abiCacheSize),
+	}
+
+	if parsed, err := contracts.ContractsMetaData.GetAbi(); err == nil {
+		registry.fallback = append(registry.fallback, parsed)
+	} else {
+		log.Printf("Warning: failed to parse contract binding ABI: %v", err)
+	}
+
+	return registry
+}
+
+// seed registers the embedded SimplePersonInfo ABI for the configured contract address,
+// unless an ABI was already uploaded for it
+func (r *abiRegistry) seed(ctx context.Context, contractAddress string) error {
+	if !common.IsHexAddress(contractAddress) {
+		return nil
+	}
+
+	address := common.HexToAddress(contractAddress)
+	existing, err := r.repo.GetByAddress(ctx, address.Hex())
+	if err != nil || existing != nil {
+		return err
+	}
+
+	_, err = r.Register(ctx, address, "SimplePersonInfo", embedded.SimplePersonInfo)
+	return err
+}
+
+// Register validates and stores the ABI of a contract
+func (r *abiRegistry) Register(ctx context.Context, address common.Address, name, abiJSON string) (*models.ContractABI, error) {
+	parsed, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ABI: %w", err)
+	}
+
+	contractABI, err := r.repo.Upsert(ctx, &models.ContractABI{
+		Address: address.Hex(),
+		Name:    name,
+		ABI:     abiJSON,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.cache.Add(address, abiCacheEntry{parsed: &parsed})
+
+	return contractABI, nil
+}
+
+// lookup returns the ABIs to try for a contract: its registered ABI, then the fallbacks
+func (r *abiRegistry) lookup(ctx context.Context, address common.Address) []*abi.ABI {
+	entry, cached := r.cache.Get(address)
+	if cached && entry.parsed == nil && time.Now().After(entry.expires) {
+		cached = false
+	}
+
+	if !cached {
+		contractABI, err := r.repo.GetByAddress(ctx, address.Hex())
+		if err != nil {
+			log.Printf("Warning: failed to load ABI of %s: %v", address.Hex(), err)
+			return r.fallback
+		}
+
+		entry = abiCacheEntry{}
+		if contractABI != nil {
+			if p, err := abi.JSON(strings.NewReader(contractABI.ABI)); err == nil {
+				entry.parsed = &p
+			}
+		}
+
+		// Cache missing ABIs for a while too, so unknown contracts don't hit the database every time
+		if entry.parsed == nil {
+			entry.expires = time.Now().Add(missingABITTL)
+		}
+		r.cache.Add(address, entry)
+	}
+
+	parsed := entry.parsed
+	if parsed == nil {
+		return r.fallback
+	}
+	return append([]*abi.ABI{parsed}, r.fallback...)
+}
+
+// DecodeInput decodes the input of a call to the given contract.
+// It returns nil if the input doesn't match a method of a known ABI.
+func (r *abiRegistry) DecodeInput(ctx context.Context, to string, input string) *DecodedCall {
+	data, err := hex.DecodeString(strings.TrimPrefix(input, "0x"))
+	if err != nil || len(data) < 4 || !common.IsHexAddress(to) {
+		return nil
+	}
+
+	for _, parsed := range r.lookup(ctx, common.HexToAddress(to)) {
+		method, err := parsed.MethodById(data[:4])
+		if err != nil {
+			continue
+		}
+
+		values, err := method.Inputs.Unpack(data[4:])
+		if err != nil {
+			continue
+		}
+
+		return &DecodedCall{
+			Method:    method.Name,
+			Signature: method.Sig,
+			Args:      namedArgs(method.Inputs, values),
+		}
+	}
+
+	return nil
+}
+
+// DecodeLog decodes a transaction log.
+// It returns nil if the log doesn't match an event of a known ABI.
+func (r *abiRegistry) DecodeLog(ctx context.Context, transactionLog *models.TransactionLog) *DecodedEvent {
+	if len(transactionLog.Topics) == 0 || !common.IsHexAddress(transactionLog.Address) {
+		return nil
+	}
+
+	data, err := hex.DecodeString(transactionLog.Data)
+	if err != nil {
+		return nil
+	}
+
+	topics := make([]common.Hash, len(transactionLog.Topics))
+	for i, topic := range transactionLog.Topics {
+		topics[i] = common.HexToHash(topic)
+	}
+
+	for _, parsed := range r.lookup(ctx, common.HexToAddress(transactionLog.Address)) {
+		event, err := parsed.EventByID(topics[0])
+		if err != nil {
+			continue
+		}
+
+		values, err := event.Inputs.NonIndexed().Unpack(data)
+		if err != nil {
+			continue
+		}
+		args := namedArgs(event.Inputs.NonIndexed(), values)
+
+		var indexed abi.Arguments
+		for _, input := range event.Inputs {
+			if input.Indexed {
+				indexed = append(indexed, input)
+			}
+		}
+		indexedValues := make(map[string]any)
+		if err := abi.ParseTopicsIntoMap(indexedValues, indexed, topics[1:]); err != nil {
+			continue
+		}
+		for name, value := range indexedValues {
+			args[name] = normalizeArg(value)
+		}
+
+		return &DecodedEvent{
+			LogIndex:  transactionLog.LogIndex,
+			Address:   transactionLog.Address,
+			Event:     event.Name,
+			Signature: event.Sig,
+			Args:      args,
+		}
+	}
+
+	return nil
+}
+
+// namedArgs maps decoded values to their argument names, naming unnamed arguments by position
+func namedArgs(arguments abi.Arguments, values []any) map[string]any {
+	args := make(map[string]any, len(values))
+	for i, value := range values {
+		name := arguments[i].Name
+		if name == "" {
+			name = fmt.Sprintf("arg%d", i)
+		}
+		args[name] = normalizeArg(value)
+	}
+	return args
+}
+
+// normalizeArg converts decoded values to JSON friendly representations,
+// keeping integers as decimal strings so they don't lose precision
+func normalizeArg(value any) any {
+	switch v := value.(type) {
+	case *big.Int:
+		return v.String()
+	case common.Address:
+		return v.Hex()
+	case common.Hash:
+		return v.Hex()
+	case []byte:
+		return "0x" + hex.EncodeToString(v)
+	case [32]byte:
+		return common.Hash(v).Hex()
+	default:
+		return v
+	}
+}
+
+// decodeTransactions adds the decoded input and events to the transaction responses
+func (s *Server) decodeTransactions(ctx context.Context, responses []TransactionResponse) {
+	if len(responses) == 0 {
+		return
+	}
+
+	hashes := make([]string, len(responses))
+	for i, response := range responses {
+		hashes[i] = response.TransactionHash
+	}
+
+	logs, err := s.store.transactionLogRepo.GetByTransactionHashes(ctx, hashes)
+	if err != nil {
+		log.Printf("Warning: failed to load transaction logs: %v", err)
+	}
+
+	logsByHash := make(map[string][]*models.TransactionLog)
+	for _, transactionLog := range logs {
+		logsByHash[transactionLog.TransactionHash] = append(logsByHash[transactionLog.TransactionHash], transactionLog)
+	}
+
+	for i := range responses {
+		responses[i].DecodedInput = s.abis.DecodeInput(ctx, responses[i].To, responses[i].Input)
+
+		for _, transactionLog := range logsByHash[responses[i].TransactionHash] {
+			if event := s.abis.DecodeLog(ctx, transactionLog); event != nil {
+				responses[i].DecodedEvents = append(responses[i].DecodedEvents, *event)
+			}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"encoding/hex"
+	"math/big"
+	"testing"
+	"time"
+
+	embedded "ethereum-fetcher-go/internal/abi"
+	"ethereum-fetcher-go/internal/models"
+	"ethereum-fetcher-go/internal/repository"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+)
+
+// fakeContractABIRepo is a repository.ContractABIRepository storing ABIs in memory
+type fakeContractABIRepo struct {
+	repository.ContractABIRepository
+	abis    map[string]*models.ContractABI
+	lookups int
+}
+
+func (r *fakeContractABIRepo) GetByAddress(_ context.Context, address string) (*models.ContractABI, error) {
+	r.lookups++
+	return r.abis[address], nil
+}
+
+func (r *fakeContractABIRepo) Upsert(_ context.Context, contractABI *models.ContractABI) (*models.ContractABI, error) {
+	r.abis[contractABI.Address] = contractABI
+	return contractABI, nil
+}
+
+func TestABIRegistryDecode(t *testing.T) {
+	contract := common.HexToAddress("0xdAf5794A77d20f773969876ec7AD7b6Ee30727b4")
+	registry := newABIRegistry(&fakeContractABIRepo{abis: make(map[string]*models.ContractABI)})
+	if err := registry.seed(context.Background(), contract.Hex()); err != nil {
+		t.Fatalf("seed() returned error: %v", err)
+	}
+
+	parsed := registry.lookup(context.Background(), contract)[0]
+	if len(parsed.Methods) == 0 {
+		t.Fatal("expected seeded ABI to define methods")
+	}
+
+	input, err := parsed.Pack("setPersonInfo", "Alice", big.NewInt(30))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	call := registry.DecodeInput(context.Background(), contract.Hex(), hex.EncodeToString(input))
+	if call == nil {
+		t.Fatal("expected input to be decoded")
+	}
+	if call.Method != "setPersonInfo" || call.Args["_name"] != "Alice" || call.Args["_age"] != "30" {
+		t.Errorf("unexpected decoded call: %+v", call)
+	}
+
+	event := parsed.Events["PersonInfoUpdated"]
+	data, err := event.Inputs.NonIndexed().Pack("Alice", big.NewInt(30))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := registry.DecodeLog(context.Background(), &models.TransactionLog{
+		Address: contract.Hex(),
+		Topics:  []string{event.ID.Hex(), common.BigToHash(big.NewInt(4)).Hex()},
+		Data:    hex.EncodeToString(data),
+	})
+	if decoded == nil {
+		t.Fatal("expected log to be decoded")
+	}
+	if decoded.Event != "PersonInfoUpdated" || decoded.Args["personIndex"] != "4" || decoded.Args["newName"] != "Alice" || decoded.Args["newAge"] != "30" {
+		t.Errorf("unexpected decoded event: %+v", decoded)
+	}
+
+	// Unknown contracts fall back to the ABI of the contract binding
+	other := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	if call := registry.DecodeInput(context.Background(), other.Hex(), hex.EncodeToString(input)); call == nil || call.Method != "setPersonInfo" {
+		t.Errorf("expected fallback decoding for unknown contract, got %+v", call)
+	}
+}
+
+func TestABIRegistryCache(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeContractABIRepo{abis: make(map[string]*models.ContractABI)}
+	registry := newABIRegistry(repo)
+	registry.cache = lru.NewCache[common.Address, abiCacheEntry](2)
+	contract := common.HexToAddress("0xdAf5794A77d20f773969876ec7AD7b6Ee30727b4")
+
+	// Missing ABIs are cached until they expire
+	registry.lookup(ctx, contract)
+	registry.lookup(ctx, contract)
+	if repo.lookups != 1 {
+		t.Errorf("expected 1 database lookup for a cached missing ABI, got %d", repo.lookups)
+	}
+
+	// An ABI registered through another instance is picked up once the missing entry expires
+	repo.abis[contract.Hex()] = &models.ContractABI{Address: contract.Hex(), ABI: embedded.SimplePersonInfo}
+	registry.cache.Add(contract, abiCacheEntry{expires: time.Now().Add(-time.Second)})
+	if parsed := registry.lookup(ctx, contract); len(parsed) != len(registry.fallback)+1 {
+		t.Errorf("expected the registered ABI after the missing entry expired, got %d ABIs", len(parsed))
+	}
+
+	// The cache is bounded, evicting the least recently used contracts
+	for i := range 5 {
+		registry.lookup(ctx, common.BigToAddress(big.NewInt(int64(i+1))))
+	}
+	if registry.cache.Len() != 2 {
+		t.Errorf("expected the cache to hold 2 contracts, got %d", registry.cache.Len())
+	}
+}
@@ -0,0 +1,193 @@
+package server
+
+import (
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"ethereum-fetcher-go/internal/auth"
+	"ethereum-fetcher-go/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// userContextKey is the context key of the authenticated *models.User
+	userContextKey = "user"
+
+	// claimsContextKey is the context key of the *auth.Claims of the access token
+	claimsContextKey = "claims"
+
+	// apiKeyLastUsedResolution is how stale the recorded last use of an API key may get
+	apiKeyLastUsedResolution = time.Minute
+)
+
+// RequireAuth rejects requests without a valid access token and stores the authenticated user in the context.
+// API keys are accepted instead of an access token only if they were granted all of the given scopes.
+func (s *Server) RequireAuth(scopes ...string) gin.HandlerFunc {
+	return s.authenticate(true, scopes)
+}
+
+// OptionalAuth authenticates requests carrying an access token or API key and lets anonymous requests through
+func (s *Server) OptionalAuth(scopes ...string) gin.HandlerFunc {
+	return s.authenticate(false, scopes)
+}
+
+func (s *Server) authenticate(required bool, scopes []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		apiKey, tokenString := requestCredentials(c)
+
+		var user *models.User
+		var ok bool
+		switch {
+		case apiKey != "":
+			user, ok = s.authenticateAPIKey(c, apiKey, scopes)
+		case tokenString != "":
+			user, ok = s.authenticateToken(c, tokenString)
+		case required:
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "No token provided"})
+			return
+		default:
+			c.Next()
+			return
+		}
+		if !ok {
+			return
+		}
+
+		c.Set(userContextKey, user)
+		c.Next()
+	}
+}
+
+// RequireRole rejects requests of users without the role. It must follow RequireAuth.
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := currentUser(c)
+		if user == nil || !auth.HasRole(user.Roles, role) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Requires the " + role + " role"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// authenticateToken resolves the user of an access token, aborting the request if it is not valid
+func (s *Server) authenticateToken(c *gin.Context, tokenString string) (*models.User, bool) {
+	claims, err := s.tokens.Parse(tokenString)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		return nil, false
+	}
+
+	revoked, err := s.store.revokedTokenRepo.IsRevoked(c, claims.ID)
+	if err != nil {
+		log.Printf("Error: failed to check token revocation: %v", err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify token"})
+		return nil, false
+	}
+	if revoked {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		return nil, false
+	}
+
+	userID, err := claims.UserID()
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		return nil, false
+	}
+
+	user, ok := s.loadUser(c, userID)
+	if ok {
+		c.Set(claimsContextKey, claims)
+	}
+	return user, ok
+}
+
+// authenticateAPIKey resolves the user of an API key, aborting the request if the key is not valid
+// or lacks one of the scopes. Endpoints that don't declare scopes don't accept API keys.
+func (s *Server) authenticateAPIKey(c *gin.Context, key string, scopes []string) (*models.User, bool) {
+	apiKey, err := s.store.apiKeyRepo.GetByHash(c, auth.HashToken(key))
+	if err != nil {
+		log.Printf("Error: failed to load API key: %v", err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify API key"})
+		return nil, false
+	}
+
+	now := time.Now()
+	if apiKey == nil || !apiKey.Active(now) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+		return nil, false
+	}
+
+	if len(scopes) == 0 {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API keys are not accepted for this endpoint"})
+		return nil, false
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(apiKey.Scopes, scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key lacks the " + scope + " scope"})
+			return nil, false
+		}
+	}
+
+	// Usage is tracked with a coarse resolution so busy keys don't cause a write per request
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > apiKeyLastUsedResolution {
+		if err := s.store.apiKeyRepo.UpdateLastUsed(c, apiKey.ID, now); err != nil {
+			log.Printf("Warning: failed to update last use of API key %d: %v", apiKey.ID, err)
+		}
+	}
+
+	return s.loadUser(c, apiKey.UserID)
+}
+
+// loadUser loads an authenticated user, aborting the request if the user no longer exists
+func (s *Server) loadUser(c *gin.Context, userID int) (*models.User, bool) {
+	user, err := s.store.userRepo.GetByID(c, userID)
+	if err != nil {
+		log.Printf("Error: failed to load user %d: %v", userID, err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to load user"})
+		return nil, false
+	}
+	if user == nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		return nil, false
+	}
+	return user, true
+}
+
+// requestCredentials returns the API key or access token of a request. API keys are passed in the
+// X-API-Key header or with the "ApiKey" Authorization scheme. Access tokens are expected with the
+// standard "Bearer" scheme, but bare tokens are still accepted for existing clients.
+func requestCredentials(c *gin.Context) (apiKey, accessToken string) {
+	if key := strings.TrimSpace(c.GetHeader("X-API-Key")); key != "" {
+		return key, ""
+	}
+
+	header := strings.TrimSpace(c.GetHeader("Authorization"))
+	if scheme, credential, found := strings.Cut(header, " "); found {
+		switch {
+		case strings.EqualFold(scheme, "Bearer"):
+			return "", strings.TrimSpace(credential)
+		case strings.EqualFold(scheme, "ApiKey"):
+			return strings.TrimSpace(credential), ""
+		}
+	}
+	return "", header
+}
+
+// currentUser returns the authenticated user, or nil for anonymous requests
+func currentUser(c *gin.Context) *models.User {
+	user, _ := c.Get(userContextKey)
+	u, _ := user.(*models.User)
+	return u
+}
+
+// currentClaims returns the claims of the access token, or nil for anonymous and API key requests
+func currentClaims(c *gin.Context) *auth.Claims {
+	claims, _ := c.Get(claimsContextKey)
+	cl, _ := claims.(*auth.Claims)
+	return cl
+}
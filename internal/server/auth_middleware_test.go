@@ -0,0 +1,121 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ethereum-fetcher-go/internal/auth"
+	"ethereum-fetcher-go/internal/models"
+	"ethereum-fetcher-go/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeAPIKeyRepo is a repository.APIKeyRepository serving keys from memory
+type fakeAPIKeyRepo struct {
+	repository.APIKeyRepository
+	keys []*models.APIKey
+}
+
+func (r *fakeAPIKeyRepo) GetByHash(_ context.Context, keyHash string) (*models.APIKey, error) {
+	for _, key := range r.keys {
+		if key.KeyHash == keyHash {
+			return key, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeAPIKeyRepo) UpdateLastUsed(_ context.Context, id int, usedAt time.Time) error {
+	for _, key := range r.keys {
+		if key.ID == id {
+			key.LastUsedAt = &usedAt
+		}
+	}
+	return nil
+}
+
+func TestAPIKeyAuthentication(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	apiKeyRepo := &fakeAPIKeyRepo{keys: []*models.APIKey{
+		{ID: 1, UserID: 1, KeyHash: auth.HashToken("lime_reader"), Scopes: []string{auth.ScopeTransactionsRead}},
+		{ID: 2, UserID: 1, KeyHash: auth.HashToken("lime_expired"), Scopes: []string{auth.ScopeTransactionsRead}, ExpiresAt: &past},
+		{ID: 3, UserID: 1, KeyHash: auth.HashToken("lime_revoked"), Scopes: []string{auth.ScopeTransactionsRead}, RevokedAt: &past},
+	}}
+	s := &Server{store: &Store{
+		userRepo:   &fakeUserRepo{users: map[int]*models.User{1: {ID: 1, Username: "alice"}}},
+		apiKeyRepo: apiKeyRepo,
+	}}
+
+	r := gin.New()
+	ok := func(c *gin.Context) { c.String(http.StatusOK, currentUser(c).Username) }
+	r.GET("/read", s.RequireAuth(auth.ScopeTransactionsRead), ok)
+	r.GET("/write", s.RequireAuth(auth.ScopeABIsWrite), ok)
+	r.GET("/account", s.RequireAuth(), ok)
+
+	tests := []struct {
+		name       string
+		path       string
+		header     string
+		value      string
+		wantStatus int
+	}{
+		{"X-API-Key header", "/read", "X-API-Key", "lime_reader", http.StatusOK},
+		{"ApiKey scheme", "/read", "Authorization", "ApiKey lime_reader", http.StatusOK},
+		{"missing scope", "/write", "X-API-Key", "lime_reader", http.StatusForbidden},
+		{"endpoint without scopes", "/account", "X-API-Key", "lime_reader", http.StatusForbidden},
+		{"unknown key", "/read", "X-API-Key", "lime_unknown", http.StatusUnauthorized},
+		{"expired key", "/read", "X-API-Key", "lime_expired", http.StatusUnauthorized},
+		{"revoked key", "/read", "X-API-Key", "lime_revoked", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set(tt.header, tt.value)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.wantStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+
+	if apiKeyRepo.keys[0].LastUsedAt == nil {
+		t.Error("expected last use of the API key to be recorded")
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	tests := []struct {
+		name       string
+		user       *models.User
+		wantStatus int
+	}{
+		{"signer", &models.User{Roles: []string{auth.RoleViewer, auth.RoleSigner}}, http.StatusOK},
+		{"admin", &models.User{Roles: []string{auth.RoleAdmin}}, http.StatusOK},
+		{"viewer", &models.User{Roles: []string{auth.RoleViewer}}, http.StatusForbidden},
+		{"anonymous", nil, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.POST("/savePerson", func(c *gin.Context) {
+				if tt.user != nil {
+					c.Set(userContextKey, tt.user)
+				}
+			}, RequireRole(auth.RoleSigner), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/savePerson", nil))
+			if rr.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, rr.Code)
+			}
+		})
+	}
+}
@@ -2,197 +2,476 @@ package server
 
 import (
 	"context"
-	"crypto/ecdsa"
 	"encoding/hex"
-	"ethereum-fetcher-go/internal/contracts"
+	"errors"
+	"ethereum-fetcher-go/internal/auth"
+	"ethereum-fetcher-go/internal/chain"
 	"ethereum-fetcher-go/internal/models"
-	"fmt"
 	"log"
+	"math"
 	"math/big"
 	"net/http"
-	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/gin-gonic/gin"
 )
 
-// TxResponse represents a transaction response
-type TxResponse struct {
-	TxHash   string `json:"txHash"`
-	TxStatus string `json:"txStatus"`
+// Reasons reported for transaction hashes that could not be returned
+const (
+	FetchErrorNotFound    = "not_found"
+	FetchErrorRPC         = "rpc_error"
+	FetchErrorPersistence = "persistence_error"
+)
+
+// FetchError describes why a requested transaction could not be returned
+type FetchError struct {
+	TransactionHash string `json:"transactionHash"`
+	Reason          string `json:"reason"`
+	Message         string `json:"message"`
+}
+
+// TransactionsResponse contains the requested transactions and the hashes that could not be returned
+type TransactionsResponse struct {
+	Transactions []TransactionResponse `json:"transactions"`
+	Errors       []FetchError          `json:"errors,omitempty"`
 }
 
-// getClient creates and returns an Ethereum client
-func getClient() (*ethclient.Client, error) {
-	ethNodeUrl := os.Getenv("ETH_NODE_URL")
-	if ethNodeUrl == "" {
-		return nil, fmt.Errorf("ETH_NODE_URL environment variable not set")
+// credentials is the payload of the authenticate endpoint
+type credentials struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// registrationRequest is the payload of the register endpoint. Only these fields can be set by clients.
+type registrationRequest struct {
+	Username    string `json:"username" binding:"required"`
+	Password    string `json:"password" binding:"required"`
+	DisplayName string `json:"displayName" binding:"max=64"`
+}
+
+// changePasswordRequest is the payload of the change password endpoint.
+// The current password may be omitted by users who signed up with a wallet and have none.
+type changePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword" binding:"required"`
+}
+
+// updateProfileRequest is the payload of the profile update endpoint. Omitted fields are kept.
+type updateProfileRequest struct {
+	Username    *string `json:"username"`
+	DisplayName *string `json:"displayName" binding:"omitempty,max=64"`
+}
+
+// deleteAccountRequest confirms the deletion of an account with its password, if it has one
+type deleteAccountRequest struct {
+	Password string `json:"password"`
+}
+
+// refreshTokenRequest is the payload of the refresh endpoint
+type refreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+// logoutRequest optionally names a refresh token to revoke with the access token,
+// or asks to revoke all refresh tokens of the user
+type logoutRequest struct {
+	RefreshToken string `json:"refreshToken"`
+	All          bool   `json:"all"`
+}
+
+// apiKeyRequest is the payload of the API key creation endpoint
+type apiKeyRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// APIKeyResponse is returned when an API key is created. The key itself is only ever returned here.
+type APIKeyResponse struct {
+	*models.APIKey
+	Key string `json:"key"`
+}
+
+// roleRequest is the payload of the role grant endpoint
+type roleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// ProfileResponse is the profile of the authenticated user with a page of their lookup history
+type ProfileResponse struct {
+	ID          int              `json:"id"`
+	Username    string           `json:"username"`
+	DisplayName string           `json:"display_name"`
+	Roles       []string         `json:"roles"`
+	CreatedAt   time.Time        `json:"created_at"`
+	Wallets     []*models.Wallet `json:"wallets"`
+	APIKeyCount int64            `json:"api_key_count"`
+	History     HistoryResponse  `json:"history"`
+}
+
+// HistoryResponse is a page of the transaction hashes a user looked up
+type HistoryResponse struct {
+	Items  []HistoryEntry `json:"items"`
+	Total  int64          `json:"total"`
+	Limit  int            `json:"limit"`
+	Offset int            `json:"offset"`
+}
+
+// TransactionPage is a page of the stored transactions
+type TransactionPage struct {
+	Items  []TransactionResponse `json:"items"`
+	Total  int64                 `json:"total"`
+	Limit  int                   `json:"limit"`
+	Offset int                   `json:"offset"`
+}
+
+// HistoryEntry is a transaction hash looked up by a user
+type HistoryEntry struct {
+	TransactionHash string    `json:"transaction_hash"`
+	LookedUpAt      time.Time `json:"looked_up_at"`
+}
+
+// userIDParam parses the user ID in the path, responding with an error if it is invalid
+func userIDParam(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return 0, false
+	}
+	return id, true
+}
+
+// updateRoles applies update to the roles of the user with the given ID and responds with the updated user
+func (s *Server) updateRoles(c *gin.Context, id int, role string, update func(roles []string) []string) {
+	if err := auth.ValidateRole(role); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	client, err := ethclient.Dial(ethNodeUrl)
+	user, err := s.store.userRepo.GetByID(c, id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to Ethereum node: %w", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
 	}
 
-	return client, nil
-}
+	user.Roles = update(user.Roles)
+	if err := s.store.userRepo.UpdateRoles(c, user.ID, user.Roles); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-// handleError is a helper function to consistently handle errors
-func handleError(c *gin.Context, status int, err error, message string) {
-	log.Printf("Error: %s: %v", message, err)
-	c.JSON(status, gin.H{"error": message})
+	log.Printf("User %d changed the roles of user %d to %v", currentUser(c).ID, user.ID, user.Roles)
+	c.JSON(http.StatusOK, user)
 }
 
-// fetchTransactionsFromNetwork fetches transaction details from the Ethereum network
-func fetchTransactionsFromNetwork(c *gin.Context, transactionHashes []string, existingTransactions map[string]bool, s *Server) ([]*models.Transaction, error) {
-	client, err := getClient()
+// lockedOut reports whether password checks of the username from the client IP are locked out,
+// responding with the time to wait if they are. Otherwise the check is counted as failed until
+// loginSucceeded is called.
+func (s *Server) lockedOut(c *gin.Context, username string) bool {
+	retryAfter, err := s.logins.Reserve(c, username, c.ClientIP())
 	if err != nil {
-		handleError(c, http.StatusInternalServerError, err, "Failed to initialize Ethereum client")
-		return nil, fmt.Errorf("failed to get client: %w", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return true
+	}
+	if retryAfter > 0 {
+		s.recordFailedLogin(c, username, models.LoginFailureLockedOut)
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed login attempts, try again later"})
+		return true
 	}
+	return false
+}
 
-	var newTransactions []*models.Transaction
+// recordFailedLogin writes a failed login to the audit log. It already counts towards the lockout
+// of the username and client IP, since lockedOut counts every check as failed until it succeeds.
+func (s *Server) recordFailedLogin(c *gin.Context, username string, reason string) {
+	if err := s.store.loginAttemptRepo.Create(c, &models.LoginAttempt{Username: username, IP: c.ClientIP(), Reason: reason}); err != nil {
+		log.Printf("Warning: failed to write login audit record: %v", err)
+	}
+}
 
-	for _, hash := range transactionHashes {
-		if existingTransactions[hash] {
-			continue
-		}
+// loginSucceeded takes back the failure counted by lockedOut after a successful password check
+// and forgets the failed logins of the username
+func (s *Server) loginSucceeded(c *gin.Context, username string) {
+	if err := s.logins.Succeed(c, username, c.ClientIP()); err != nil {
+		log.Printf("Warning: failed to reset failed logins of %s: %v", username, err)
+	}
+}
 
-		txHash := common.HexToHash(hash)
-		tx, _, err := client.TransactionByHash(c, txHash)
-		if err != nil {
-			log.Printf("Warning: failed to fetch transaction %s: %v", hash, err)
-			continue
+// confirmPassword checks the password of the authenticated user before sensitive account changes,
+// subject to the same lockout as logins. Users without a password, who signed up with a wallet,
+// are confirmed by their access token alone. It responds with an error if the password is wrong.
+func (s *Server) confirmPassword(c *gin.Context, user *models.User, password string) bool {
+	if user.Password == "" {
+		return true
+	}
+
+	if s.lockedOut(c, user.Username) {
+		return false
+	}
+
+	if match, _ := auth.CheckPassword(user.Password, password); !match {
+		s.recordFailedLogin(c, user.Username, models.LoginFailureInvalidCredentials)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid password"})
+		return false
+	}
+	s.loginSucceeded(c, user.Username)
+	return true
+}
+
+// orderByHashes returns the transactions in the order of the requested hashes.
+// Each transaction is returned once, even if its hash was requested more than once.
+func orderByHashes(hashes []string, transactions []*models.Transaction) []*models.Transaction {
+	byHash := make(map[string]*models.Transaction, len(transactions))
+	for _, tx := range transactions {
+		byHash[strings.ToLower(tx.TransactionHash)] = tx
+	}
+
+	ordered := make([]*models.Transaction, 0, len(transactions))
+	for _, hash := range hashes {
+		key := strings.ToLower(hash)
+		if tx, ok := byHash[key]; ok {
+			ordered = append(ordered, tx)
+			delete(byHash, key)
 		}
+	}
+
+	return ordered
+}
 
-		receipt, err := client.TransactionReceipt(c, txHash)
-		if err != nil {
-			log.Printf("Warning: failed to fetch receipt for %s: %v", hash, err)
+// fetchTransactionsFromNetwork fetches transaction details from the Ethereum network.
+// Hashes are fetched concurrently, bounded by the server's fetch concurrency, and the
+// result preserves the order of the requested hashes. Hashes that could not be
+// fetched or stored are reported in the returned errors.
+func fetchTransactionsFromNetwork(c *gin.Context, transactionHashes []string, existingTransactions map[string]bool, s *Server) ([]*models.Transaction, []FetchError) {
+	// Skip stored and duplicate hashes so each hash is fetched at most once
+	seen := make(map[string]bool)
+	var hashesToFetch []string
+	for _, hash := range transactionHashes {
+		key := strings.ToLower(hash)
+		if existingTransactions[key] || seen[key] {
 			continue
 		}
+		seen[key] = true
+		hashesToFetch = append(hashesToFetch, hash)
+	}
+
+	ctx := c.Request.Context()
+	transactions := make([]*models.Transaction, len(hashesToFetch))
+	fetchErrors := make([]*FetchError, len(hashesToFetch))
+	sem := make(chan struct{}, s.fetchConcurrency)
+	var wg sync.WaitGroup
+
+	for i, hash := range hashesToFetch {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			transactions[i], fetchErrors[i] = fetchTransaction(ctx, s.eth, hash, s)
+		}()
+	}
+	wg.Wait()
 
-		from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
-		if err != nil {
-			log.Printf("Warning: failed to get sender for %s: %v", hash, err)
+	var newTransactions []*models.Transaction
+	var failed []FetchError
+	for i := range hashesToFetch {
+		if fetchErrors[i] != nil {
+			failed = append(failed, *fetchErrors[i])
 			continue
 		}
+		newTransactions = append(newTransactions, transactions[i])
+	}
 
-		transaction := &models.Transaction{
-			TransactionHash:   tx.Hash().Hex(),
-			TransactionStatus: int(receipt.Status),
-			To:                tx.To().Hex(),
-			From:              from.Hex(),
-			ContractAddress:   tx.To().Hex(),
-			LogsCount:         len(receipt.Logs),
-			Value:             int(tx.Value().Int64()),
-			BlockHash:         receipt.BlockHash.Hex(),
-			BlockNumber:       int(receipt.BlockNumber.Int64()),
-			Input:             hex.EncodeToString(tx.Data()),
+	return newTransactions, failed
+}
+
+// fetchTransaction fetches a single transaction and its receipt and stores it.
+// Pending transactions are stored without a receipt and completed later by the reconciler.
+// It returns a FetchError describing the failure if the transaction could not be fetched or saved.
+func fetchTransaction(ctx context.Context, client chain.Backend, hash string, s *Server) (*models.Transaction, *FetchError) {
+	txHash := common.HexToHash(hash)
+	tx, isPending, err := client.TransactionByHash(ctx, txHash)
+	if err != nil {
+		if errors.Is(err, ethereum.NotFound) {
+			return nil, newFetchError(hash, FetchErrorNotFound, "transaction not found", err)
 		}
+		return nil, newFetchError(hash, FetchErrorRPC, "failed to fetch transaction", err)
+	}
 
-		if _, err := s.store.transactionRepo.Create(c, transaction); err != nil {
-			log.Printf("Warning: failed to save transaction %s: %v", hash, err)
-			continue
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return nil, newFetchError(hash, FetchErrorRPC, "failed to recover transaction sender", err)
+	}
+
+	var receipt *types.Receipt
+	if !isPending {
+		receipt, err = client.TransactionReceipt(ctx, txHash)
+		// A mined transaction whose receipt is not indexed yet is treated as pending
+		if err != nil && !errors.Is(err, ethereum.NotFound) {
+			return nil, newFetchError(hash, FetchErrorRPC, "failed to fetch transaction receipt", err)
 		}
+	}
 
-		newTransactions = append(newTransactions, transaction)
+	// A concurrent request may have stored the transaction first, in which case that one is returned
+	transaction, err := s.store.transactionRepo.Create(ctx, newTransactionModel(tx, from, receipt))
+	if err != nil {
+		return nil, newFetchError(hash, FetchErrorPersistence, "failed to save transaction", err)
 	}
 
-	return newTransactions, nil
+	return transaction, nil
 }
 
-// savePersonToContract saves person information to the smart contract and returns transaction details
-func savePersonToContract(c *gin.Context, personData struct {
-	Name string `json:"name"`
-	Age  int    `json:"age"`
-}) (*TxResponse, error) {
-	client, err := getClient()
-	if err != nil {
-		handleError(c, http.StatusInternalServerError, err, "Failed to initialize Ethereum client")
-		return nil, fmt.Errorf("failed to get client: %w", err)
+// newTransactionModel builds the stored representation of a transaction.
+// A nil receipt marks the transaction as pending.
+func newTransactionModel(tx *types.Transaction, from common.Address, receipt *types.Receipt) *models.Transaction {
+	transaction := &models.Transaction{
+		TransactionHash: tx.Hash().Hex(),
+		Status:          models.TransactionStatusPending,
+		From:            from.Hex(),
+		Nonce:           tx.Nonce(),
+		Value:           models.NewBigInt(tx.Value()),
+		Input:           hex.EncodeToString(tx.Data()),
+		Type:            int(tx.Type()),
+		ChainID:         tx.ChainId().Uint64(),
+		Gas:             tx.Gas(),
+		GasPrice:        models.NewBigInt(tx.GasPrice()),
 	}
 
-	address := common.HexToAddress(os.Getenv("CONTRACT_ADDRESS"))
-	if address == common.HexToAddress("0x0") {
-		handleError(c, http.StatusInternalServerError, fmt.Errorf("invalid contract address"), "Invalid contract address")
-		return nil, fmt.Errorf("invalid contract address")
+	// Fee caps only exist on EIP-1559 style transactions; legacy ones report their gas price instead
+	if tx.Type() >= types.DynamicFeeTxType {
+		transaction.MaxFeePerGas = models.NewBigInt(tx.GasFeeCap())
+		transaction.MaxPriorityFeePerGas = models.NewBigInt(tx.GasTipCap())
 	}
 
-	instance, err := contracts.NewContracts(address, client)
-	if err != nil {
-		handleError(c, http.StatusInternalServerError, err, "Failed to initialize contract")
-		return nil, fmt.Errorf("failed to initialize contract: %w", err)
+	if tx.Type() == types.BlobTxType {
+		transaction.BlobGas = tx.BlobGas()
+		transaction.MaxFeePerBlobGas = models.NewBigInt(tx.BlobGasFeeCap())
+		for _, hash := range tx.BlobHashes() {
+			transaction.BlobVersionedHashes = append(transaction.BlobVersionedHashes, hash.Hex())
+		}
 	}
 
-	privateKey, err := crypto.HexToECDSA(os.Getenv("PRIVATE_KEY"))
-	if err != nil {
-		handleError(c, http.StatusInternalServerError, err, "Invalid private key")
-		return nil, fmt.Errorf("invalid private key: %w", err)
+	// Contract creation transactions have no recipient
+	if tx.To() != nil {
+		transaction.To = tx.To().Hex()
 	}
 
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		handleError(c, http.StatusInternalServerError, fmt.Errorf("invalid public key"), "Failed to process public key")
-		return nil, fmt.Errorf("failed to process public key")
+	if receipt != nil {
+		applyReceipt(transaction, receipt)
 	}
 
-	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
-	nonce, err := client.PendingNonceAt(context.Background(), fromAddress)
-	if err != nil {
-		handleError(c, http.StatusInternalServerError, err, "Failed to get nonce")
-		return nil, fmt.Errorf("failed to get nonce: %w", err)
+	return transaction
+}
+
+// applyReceipt completes a transaction with the fields of its receipt and marks it as mined
+func applyReceipt(transaction *models.Transaction, receipt *types.Receipt) {
+	transaction.Status = models.TransactionStatusMined
+	transaction.TransactionStatus = int(receipt.Status)
+	transaction.LogsCount = len(receipt.Logs)
+	transaction.BlockHash = receipt.BlockHash.Hex()
+	transaction.BlockNumber = receipt.BlockNumber.Uint64()
+	transaction.TransactionIndex = receipt.TransactionIndex
+	transaction.GasUsed = receipt.GasUsed
+	transaction.CumulativeGasUsed = receipt.CumulativeGasUsed
+	transaction.EffectiveGasPrice = models.NewBigInt(receipt.EffectiveGasPrice)
+	transaction.BlobGasUsed = receipt.BlobGasUsed
+	transaction.BlobGasPrice = models.NewBigInt(receipt.BlobGasPrice)
+
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), &transaction.EffectiveGasPrice.Int)
+	blobFee := new(big.Int).Mul(new(big.Int).SetUint64(receipt.BlobGasUsed), &transaction.BlobGasPrice.Int)
+	transaction.Fee = models.NewBigInt(fee.Add(fee, blobFee))
+
+	transaction.Logs = make([]models.TransactionLog, len(receipt.Logs))
+	for i, receiptLog := range receipt.Logs {
+		transaction.Logs[i] = newTransactionLogModel(transaction.TransactionHash, receiptLog)
 	}
 
-	gasPrice, err := client.SuggestGasPrice(context.Background())
-	if err != nil {
-		handleError(c, http.StatusInternalServerError, err, "Failed to get gas price")
-		return nil, fmt.Errorf("failed to get gas price: %w", err)
+	// The receipt only carries a contract address for contract creation transactions
+	if receipt.ContractAddress != (common.Address{}) {
+		transaction.ContractAddress = receipt.ContractAddress.Hex()
 	}
+}
 
-	increasedGasPrice := new(big.Int).Mul(gasPrice, big.NewInt(130))
-	increasedGasPrice = increasedGasPrice.Div(increasedGasPrice, big.NewInt(100))
+// newTransactionLogModel builds the stored representation of a receipt log
+func newTransactionLogModel(transactionHash string, receiptLog *types.Log) models.TransactionLog {
+	transactionLog := models.TransactionLog{
+		TransactionHash: transactionHash,
+		LogIndex:        receiptLog.Index,
+		Address:         receiptLog.Address.Hex(),
+		Topics:          make([]string, len(receiptLog.Topics)),
+		Data:            hex.EncodeToString(receiptLog.Data),
+		BlockNumber:     receiptLog.BlockNumber,
+		Removed:         receiptLog.Removed,
+	}
 
-	chainID, err := client.ChainID(context.Background())
-	if err != nil {
-		handleError(c, http.StatusInternalServerError, err, "Failed to get chain ID")
-		return nil, fmt.Errorf("failed to get chain ID: %w", err)
+	for i, topic := range receiptLog.Topics {
+		transactionLog.Topics[i] = topic.Hex()
 	}
+	if len(receiptLog.Topics) > 0 {
+		transactionLog.Topic0 = receiptLog.Topics[0].Hex()
+	}
+
+	return transactionLog
+}
 
-	auth, err := bind.NewKeyedTransactorWithChainID(privateKey, chainID)
+// newFetchError logs the cause of a failed fetch and returns the error reported to the client
+func newFetchError(hash, reason, message string, err error) *FetchError {
 	if err != nil {
-		handleError(c, http.StatusInternalServerError, err, "Failed to create transaction authenticator")
-		return nil, fmt.Errorf("failed to create transaction authenticator: %w", err)
+		log.Printf("Warning: %s %s: %v", message, hash, err)
 	}
 
-	auth.Nonce = big.NewInt(int64(nonce))
-	auth.Value = big.NewInt(0)
-	auth.GasLimit = uint64(500000)
-	auth.GasPrice = increasedGasPrice
+	return &FetchError{
+		TransactionHash: hash,
+		Reason:          reason,
+		Message:         message,
+	}
+}
 
-	txHash, err := instance.SetPersonInfo(auth, personData.Name, big.NewInt(int64(personData.Age)))
-	if err != nil {
-		handleError(c, http.StatusInternalServerError, err, "Failed to set person information")
-		return nil, fmt.Errorf("failed to set person information: %w", err)
+// replaceOutgoing replaces the outgoing transaction with the ID in the path and responds with its job
+func (s *Server) replaceOutgoing(c *gin.Context, cancel bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
 	}
 
-	receipt, err := bind.WaitMined(context.Background(), client, txHash)
+	// The job is loaded under its lock, so it includes any replacement made by the watcher meanwhile
+	unlock := s.jobLocks.lock(id)
+	defer unlock()
+
+	job, err := s.store.outgoingRepo.GetByID(c, id)
 	if err != nil {
-		handleError(c, http.StatusInternalServerError, err, "Failed to confirm transaction")
-		return nil, fmt.Errorf("failed to confirm transaction: %w", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if job == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
 	}
 
-	txStatus := "success"
-	if receipt.Status == 0 {
-		txStatus = "failed"
+	if err := s.replace(context.WithoutCancel(c), job, cancel); err != nil {
+		switch {
+		case errors.Is(err, errNotPending):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "job": job})
+		case errors.Is(err, chain.ErrFeeTooHigh):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		}
+		return
 	}
 
-	return &TxResponse{
-		TxHash:   txHash.Hash().Hex(),
-		TxStatus: txStatus,
-	}, nil
+	c.JSON(http.StatusOK, job)
 }
@@ -0,0 +1,410 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ethereum-fetcher-go/internal/chain"
+	"ethereum-fetcher-go/internal/models"
+	"ethereum-fetcher-go/internal/repository"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gin-gonic/gin"
+)
+
+var testChainID = big.NewInt(11155111)
+
+// fakeBackend is a chain.Backend serving transactions and receipts from memory
+type fakeBackend struct {
+	chain.Backend
+	txs      map[common.Hash]*types.Transaction
+	receipts map[common.Hash]*types.Receipt
+	// errs fails lookups of the hashes with the errors
+	errs map[common.Hash]error
+}
+
+func (b *fakeBackend) TransactionByHash(_ context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	if err := b.errs[hash]; err != nil {
+		return nil, false, err
+	}
+	tx, ok := b.txs[hash]
+	if !ok {
+		return nil, false, ethereum.NotFound
+	}
+	_, mined := b.receipts[hash]
+	return tx, !mined, nil
+}
+
+func (b *fakeBackend) TransactionReceipt(_ context.Context, hash common.Hash) (*types.Receipt, error) {
+	receipt, ok := b.receipts[hash]
+	if !ok {
+		return nil, ethereum.NotFound
+	}
+	return receipt, nil
+}
+
+// fakeTransactionRepo is a repository.TransactionRepository storing created transactions in memory
+type fakeTransactionRepo struct {
+	repository.TransactionRepository
+	mu      sync.Mutex
+	created []*models.Transaction
+	updated []*models.Transaction
+	// failCreate fails creating the transactions with the hashes
+	failCreate map[string]bool
+}
+
+func (r *fakeTransactionRepo) Create(_ context.Context, tx *models.Transaction) (*models.Transaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.failCreate[tx.TransactionHash] {
+		return nil, errors.New("violates check constraint")
+	}
+	// Like the database, a transaction stored meanwhile is returned instead
+	for _, stored := range r.created {
+		if stored.TransactionHash == tx.TransactionHash {
+			return stored, nil
+		}
+	}
+	r.created = append(r.created, tx)
+	return tx, nil
+}
+
+func (r *fakeTransactionRepo) Update(_ context.Context, tx *models.Transaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.updated = append(r.updated, tx)
+	return nil
+}
+
+func (r *fakeTransactionRepo) GetByHash(_ context.Context, hash string) (*models.Transaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, tx := range r.created {
+		if tx.TransactionHash == hash {
+			return tx, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeTransactionRepo) GetAll(_ context.Context, limit, offset int) ([]*models.Transaction, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	start := min(offset, len(r.created))
+	end := min(start+limit, len(r.created))
+	return r.created[start:end], int64(len(r.created)), nil
+}
+
+// GetByHashes matches hashes exactly, like the database does
+func (r *fakeTransactionRepo) GetByHashes(_ context.Context, hashes []string) ([]*models.Transaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var txs []*models.Transaction
+	for _, tx := range r.created {
+		if slices.Contains(hashes, tx.TransactionHash) {
+			txs = append(txs, tx)
+		}
+	}
+	return txs, nil
+}
+
+// fakeTransactionLogRepo is a repository.TransactionLogRepository serving the logs of stored transactions
+type fakeTransactionLogRepo struct {
+	repository.TransactionLogRepository
+	logs []*models.TransactionLog
+}
+
+func (r *fakeTransactionLogRepo) GetByTransactionHash(_ context.Context, transactionHash string, filter repository.LogFilter) ([]*models.TransactionLog, error) {
+	var logs []*models.TransactionLog
+	for _, transactionLog := range r.logs {
+		if transactionLog.TransactionHash == transactionHash &&
+			(filter.Address == "" || transactionLog.Address == filter.Address) &&
+			(filter.Topic0 == "" || transactionLog.Topic0 == filter.Topic0) {
+			logs = append(logs, transactionLog)
+		}
+	}
+	return logs, nil
+}
+
+func (r *fakeTransactionLogRepo) GetByTransactionHashes(_ context.Context, hashes []string) ([]*models.TransactionLog, error) {
+	var logs []*models.TransactionLog
+	for _, transactionLog := range r.logs {
+		if slices.Contains(hashes, transactionLog.TransactionHash) {
+			logs = append(logs, transactionLog)
+		}
+	}
+	return logs, nil
+}
+
+// signTestTx signs a dynamic fee transaction sent to the given recipient, or a contract creation if to is nil
+func signTestTx(t *testing.T, key *ecdsa.PrivateKey, to *common.Address, value *big.Int, data []byte) *types.Transaction {
+	t.Helper()
+
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(testChainID), &types.DynamicFeeTx{
+		ChainID:   testChainID,
+		Nonce:     7,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(2),
+		Gas:       100000,
+		To:        to,
+		Value:     value,
+		Data:      data,
+	})
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	return tx
+}
+
+func TestFetchTransaction(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	recipient := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	deployed := crypto.CreateAddress(sender, 7)
+
+	tests := []struct {
+		name            string
+		tx              *types.Transaction
+		receipt         *types.Receipt
+		wantTo          string
+		wantContract    string
+		wantValue       string
+		wantInputPrefix string
+	}{
+		{
+			name:         "contract creation",
+			tx:           signTestTx(t, key, nil, big.NewInt(0), []byte{0x60, 0x80}),
+			receipt:      &types.Receipt{Status: types.ReceiptStatusSuccessful, ContractAddress: deployed},
+			wantTo:       "",
+			wantContract: deployed.Hex(),
+			wantValue:    "0",
+		},
+		{
+			name:         "plain transfer",
+			tx:           signTestTx(t, key, &recipient, big.NewInt(1000), nil),
+			receipt:      &types.Receipt{Status: types.ReceiptStatusSuccessful},
+			wantTo:       recipient.Hex(),
+			wantContract: "",
+			wantValue:    "1000",
+		},
+		{
+			name:            "contract call",
+			tx:              signTestTx(t, key, &recipient, big.NewInt(0), []byte{0xa9, 0x05, 0x9c, 0xbb}),
+			receipt:         &types.Receipt{Status: types.ReceiptStatusSuccessful, Logs: []*types.Log{{}}},
+			wantTo:          recipient.Hex(),
+			wantContract:    "",
+			wantValue:       "0",
+			wantInputPrefix: "a9059cbb",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.receipt.TxHash = tt.tx.Hash()
+			tt.receipt.BlockNumber = big.NewInt(42)
+			tt.receipt.GasUsed = 21000
+			tt.receipt.EffectiveGasPrice = big.NewInt(2)
+
+			backend := &fakeBackend{
+				txs:      map[common.Hash]*types.Transaction{tt.tx.Hash(): tt.tx},
+				receipts: map[common.Hash]*types.Receipt{tt.tx.Hash(): tt.receipt},
+			}
+			repo := &fakeTransactionRepo{}
+			s := &Server{store: &Store{transactionRepo: repo}}
+
+			transaction, fetchErr := fetchTransaction(context.Background(), backend, tt.tx.Hash().Hex(), s)
+			if fetchErr != nil {
+				t.Fatalf("fetchTransaction() returned error: %+v", fetchErr)
+			}
+
+			if transaction.Status != models.TransactionStatusMined {
+				t.Errorf("expected status %s, got %s", models.TransactionStatusMined, transaction.Status)
+			}
+			if transaction.From != sender.Hex() {
+				t.Errorf("expected from %s, got %s", sender.Hex(), transaction.From)
+			}
+			if transaction.To != tt.wantTo {
+				t.Errorf("expected to %q, got %q", tt.wantTo, transaction.To)
+			}
+			if transaction.ContractAddress != tt.wantContract {
+				t.Errorf("expected contract address %q, got %q", tt.wantContract, transaction.ContractAddress)
+			}
+			if transaction.Value.String() != tt.wantValue {
+				t.Errorf("expected value %s, got %s", tt.wantValue, transaction.Value.String())
+			}
+			if transaction.Nonce != 7 || transaction.Gas != 100000 || transaction.MaxFeePerGas.String() != "2" {
+				t.Errorf("unexpected transaction fields: nonce %d, gas %d, max fee %s", transaction.Nonce, transaction.Gas, transaction.MaxFeePerGas.String())
+			}
+			if transaction.Fee.String() != "42000" {
+				t.Errorf("expected fee 42000, got %s", transaction.Fee.String())
+			}
+			if transaction.LogsCount != len(tt.receipt.Logs) {
+				t.Errorf("expected %d logs, got %d", len(tt.receipt.Logs), transaction.LogsCount)
+			}
+			if tt.wantInputPrefix != "" && transaction.Input[:len(tt.wantInputPrefix)] != tt.wantInputPrefix {
+				t.Errorf("expected input to start with %s, got %s", tt.wantInputPrefix, transaction.Input)
+			}
+			if len(repo.created) != 1 {
+				t.Errorf("expected transaction to be stored once, got %d", len(repo.created))
+			}
+		})
+	}
+}
+
+func TestFetchTransactionNotFound(t *testing.T) {
+	backend := &fakeBackend{}
+	s := &Server{store: &Store{transactionRepo: &fakeTransactionRepo{}}}
+
+	hash := common.HexToHash("0x01").Hex()
+	_, fetchErr := fetchTransaction(context.Background(), backend, hash, s)
+	if fetchErr == nil || fetchErr.Reason != FetchErrorNotFound {
+		t.Fatalf("expected %s error, got %+v", FetchErrorNotFound, fetchErr)
+	}
+}
+
+// countingBackend is a chain.Service serving transactions and receipts from a fakeBackend,
+// counting transaction lookups per hash and the maximum number of lookups running at once
+type countingBackend struct {
+	chain.Service
+	backend   *fakeBackend
+	mu        sync.Mutex
+	calls     map[common.Hash]int
+	active    int
+	maxActive int
+}
+
+func (b *countingBackend) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	b.mu.Lock()
+	b.calls[hash]++
+	b.active++
+	b.maxActive = max(b.maxActive, b.active)
+	b.mu.Unlock()
+
+	// Keep the lookup running long enough for others to overlap with it
+	time.Sleep(10 * time.Millisecond)
+
+	b.mu.Lock()
+	b.active--
+	b.mu.Unlock()
+	return b.backend.TransactionByHash(ctx, hash)
+}
+
+func (b *countingBackend) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	return b.backend.TransactionReceipt(ctx, hash)
+}
+
+// newTestContext returns a gin context for a request to the fetch endpoint
+func newTestContext() *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/lime/eth", nil)
+	return c
+}
+
+func TestFetchTransactionsFromNetwork(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	recipient := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+	backend := &countingBackend{
+		backend: &fakeBackend{txs: map[common.Hash]*types.Transaction{}, receipts: map[common.Hash]*types.Receipt{}},
+		calls:   make(map[common.Hash]int),
+	}
+	var hashes []string
+	for i := range 10 {
+		tx := signTestTx(t, key, &recipient, big.NewInt(int64(i)), nil)
+		backend.backend.txs[tx.Hash()] = tx
+		backend.backend.receipts[tx.Hash()] = &types.Receipt{Status: types.ReceiptStatusSuccessful, BlockNumber: big.NewInt(42), TxHash: tx.Hash()}
+		hashes = append(hashes, tx.Hash().Hex())
+	}
+	// The first hash is requested again in upper case, and the second one is already stored
+	requested := append(hashes, "0x"+strings.ToUpper(hashes[0][2:]))
+	existing := map[string]bool{strings.ToLower(hashes[1]): true}
+
+	repo := &fakeTransactionRepo{}
+	s := &Server{eth: backend, fetchConcurrency: 3, store: &Store{transactionRepo: repo}}
+
+	transactions, fetchErrors := fetchTransactionsFromNetwork(newTestContext(), requested, existing, s)
+	if len(fetchErrors) != 0 {
+		t.Fatalf("expected no errors, got %+v", fetchErrors)
+	}
+
+	want := append([]string{hashes[0]}, hashes[2:]...)
+	if len(transactions) != len(want) {
+		t.Fatalf("expected %d transactions, got %d", len(want), len(transactions))
+	}
+	for i, tx := range transactions {
+		if tx.TransactionHash != want[i] {
+			t.Errorf("expected transaction %d to be %s, got %s", i, want[i], tx.TransactionHash)
+		}
+	}
+
+	for hash, calls := range backend.calls {
+		if calls != 1 {
+			t.Errorf("expected %s to be fetched once, got %d", hash.Hex(), calls)
+		}
+	}
+	if backend.calls[common.HexToHash(hashes[1])] != 0 {
+		t.Errorf("expected the stored transaction not to be fetched")
+	}
+	if backend.maxActive > 3 {
+		t.Errorf("expected at most 3 concurrent fetches, got %d", backend.maxActive)
+	}
+	if len(repo.created) != len(want) {
+		t.Errorf("expected %d transactions to be stored, got %d", len(want), len(repo.created))
+	}
+}
+
+func TestFetchTransactionsFromNetworkConcurrentRequests(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	recipient := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	tx := signTestTx(t, key, &recipient, big.NewInt(1), nil)
+	backend := &fakeBackend{
+		txs:      map[common.Hash]*types.Transaction{tx.Hash(): tx},
+		receipts: map[common.Hash]*types.Receipt{tx.Hash(): {Status: types.ReceiptStatusSuccessful, BlockNumber: big.NewInt(42)}},
+	}
+	repo := &fakeTransactionRepo{}
+	s := &Server{eth: &fakeChain{backend: backend}, fetchConcurrency: 1, store: &Store{transactionRepo: repo}}
+
+	// Both requests missed the transaction in the database, and only one of them can store it
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			transactions, fetchErrors := fetchTransactionsFromNetwork(newTestContext(), []string{tx.Hash().Hex()}, nil, s)
+			if len(fetchErrors) != 0 || len(transactions) != 1 {
+				t.Errorf("expected the transaction without errors, got %d transactions and %+v", len(transactions), fetchErrors)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(repo.created) != 1 {
+		t.Errorf("expected the transaction to be stored once, got %d", len(repo.created))
+	}
+}
+
+func TestOrderByHashes(t *testing.T) {
+	a := &models.Transaction{TransactionHash: "0xAA"}
+	b := &models.Transaction{TransactionHash: "0xbb"}
+	c := &models.Transaction{TransactionHash: "0xcc"}
+
+	ordered := orderByHashes([]string{"0xcc", "0xaa", "0xBB", "0xcc", "0xdd"}, []*models.Transaction{a, b, c})
+	if len(ordered) != 3 || ordered[0] != c || ordered[1] != a || ordered[2] != b {
+		t.Errorf("expected transactions in request order without duplicates, got %v", ordered)
+	}
+}
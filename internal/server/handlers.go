@@ -1,26 +1,47 @@
 package server
 
 import (
+	"encoding/json"
+	"errors"
+	"ethereum-fetcher-go/internal/auth"
+	"ethereum-fetcher-go/internal/chain"
 	"ethereum-fetcher-go/internal/models"
+	"ethereum-fetcher-go/internal/repository"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
-	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4"
 )
 
 func (s *Server) healthHandler(c *gin.Context) {
-	c.JSON(http.StatusOK, s.db.Health())
+	c.JSON(http.StatusOK, gin.H{
+		"database": s.db.Health(),
+		"ethereum": s.eth.Health(),
+	})
+}
+
+func (s *Server) jwksHandler(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, s.tokens.JWKS())
 }
 
+// getAllTransactionsHandler responds with a page of all stored transactions
 func (s *Server) getAllTransactionsHandler(c *gin.Context) {
-	txs, err := s.store.transactionRepo.GetAll(c)
+	p, _ := c.MustGet("page").(page)
+
+	txs, total, err := s.store.transactionRepo.GetAll(c, p.Limit, p.Offset)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, txs)
+	c.JSON(http.StatusOK, TransactionPage{Items: s.transactionResponses(c, txs), Total: total, Limit: p.Limit, Offset: p.Offset})
 }
 
 func (s *Server) fetchTransactionsHandler(c *gin.Context) {
@@ -41,43 +62,14 @@ func (s *Server) fetchTransactionsHandler(c *gin.Context) {
 		return
 	}
 
-	// Get the token from the request
-	tokenString := c.GetHeader("Authorization")
-	if tokenString != "" {
-		// Validate the token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return []byte(os.Getenv("JWT_SECRET")), nil
-		})
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			return
-		}
-
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			return
-		}
-
-		userId := int(claims["sub"].(float64))
+	// Remember the hashes looked up by authenticated users
+	if user := currentUser(c); user != nil {
+		for _, hashToCheck := range transactionHashes {
+			existingUserTransaction, _ := s.store.userTransactionRepo.GetByTransactionHashAndUserId(c, hashToCheck, user.ID)
 
-		foundUser, err := s.store.userRepo.GetByID(c, userId)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-
-		if foundUser != nil {
-			// Check for user transaction association
-			for _, hashToCheck := range transactionHashes {
-				existingUserTransaction, _ := s.store.userTransactionRepo.GetByTransactionHashAndUserId(c, hashToCheck, foundUser.ID)
-
-				if existingUserTransaction == nil {
-					_, err := s.store.userTransactionRepo.Create(c, foundUser.ID, hashToCheck)
-					if err != nil {
-						c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-						continue
-					}
+			if existingUserTransaction == nil {
+				if _, err := s.store.userTransactionRepo.Create(c, user.ID, hashToCheck); err != nil {
+					log.Printf("Warning: failed to record transaction %s for user %d: %v", hashToCheck, user.ID, err)
 				}
 			}
 		}
@@ -87,44 +79,158 @@ func (s *Server) fetchTransactionsHandler(c *gin.Context) {
 
 	// If all transactions exist, return them
 	if len(existingTransactions) == len(transactionHashes) {
-		c.IndentedJSON(http.StatusOK, existingTransactions)
+		c.IndentedJSON(http.StatusOK, TransactionsResponse{
+			Transactions: s.transactionResponses(c, orderByHashes(transactionHashes, existingTransactions)),
+		})
 		return
 	}
 
 	// Create a map of existing transactions for quick lookup
 	existingTxMap := make(map[string]bool)
 	for _, tx := range existingTransactions {
-		existingTxMap[tx.TransactionHash] = true
+		existingTxMap[strings.ToLower(tx.TransactionHash)] = true
 	}
 
 	// Fetch new transactions from the network
-	newTransactions, err := fetchTransactionsFromNetwork(c, transactionHashes, existingTxMap, s)
+	newTransactions, fetchErrors := fetchTransactionsFromNetwork(c, transactionHashes, existingTxMap, s)
+
+	response := TransactionsResponse{
+		Transactions: s.transactionResponses(c, orderByHashes(transactionHashes, append(existingTransactions, newTransactions...))),
+		Errors:       fetchErrors,
+	}
+
+	// Report a partial result so clients know to retry the failed hashes
+	status := http.StatusOK
+	if len(fetchErrors) > 0 {
+		status = http.StatusMultiStatus
+	}
+
+	c.IndentedJSON(status, response)
+}
+
+func (s *Server) transactionLogsHandler(c *gin.Context) {
+	hash := c.GetString("transactionHash")
+	filter, _ := c.MustGet("logFilter").(repository.LogFilter)
+
+	transaction, err := s.store.transactionRepo.GetByHash(c, hash)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Only stored transactions are served, so anonymous clients can't make the server
+	// fetch from the network here; unknown transactions are fetched through /lime/eth
+	if transaction == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found, fetch it with /lime/eth first"})
+		return
+	}
+
+	logs, err := s.store.transactionLogRepo.GetByTransactionHash(c, hash, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, logs)
+}
+
+func (s *Server) registerABIHandler(c *gin.Context) {
+	var request struct {
+		Address string          `json:"address" binding:"required"`
+		Name    string          `json:"name"`
+		ABI     json.RawMessage `json:"abi" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !common.IsHexAddress(request.Address) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid address: " + request.Address})
+		return
+	}
+
+	// The ABI may be sent either as a JSON array or as a string containing it
+	abiJSON := string(request.ABI)
+	var encoded string
+	if err := json.Unmarshal(request.ABI, &encoded); err == nil {
+		abiJSON = encoded
+	}
+
+	contractABI, err := s.abis.Register(c, common.HexToAddress(request.Address), request.Name, abiJSON)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, contractABI)
+}
+
+func (s *Server) getAllABIsHandler(c *gin.Context) {
+	contractABIs, err := s.store.contractABIRepo.GetAll(c)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	c.JSON(http.StatusOK, contractABIs)
+}
+
+func (s *Server) getABIHandler(c *gin.Context) {
+	address := c.Param("address")
+	if !common.IsHexAddress(address) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid address: " + address})
+		return
+	}
 
-	allTransactions := append(existingTransactions, newTransactions...)
+	contractABI, err := s.store.contractABIRepo.GetByAddress(c, common.HexToAddress(address).Hex())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if contractABI == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ABI not found"})
+		return
+	}
 
-	c.IndentedJSON(http.StatusOK, allTransactions)
+	c.JSON(http.StatusOK, contractABI)
 }
 
 func (s *Server) registerUserHandler(c *gin.Context) {
-	var user models.User
-	if err := c.ShouldBindJSON(&user); err != nil {
+	var request registrationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := auth.ValidateUsername(request.Username); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := auth.ValidatePassword(request.Password, request.Username); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	// Check if user already exists
-	existingUser, _ := s.store.userRepo.GetByUsername(c, user.Username)
+	existingUser, _ := s.store.userRepo.GetByUsername(c, request.Username)
 	if existingUser != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "User already exists"})
 		return
 	}
 
+	passwordHash, err := auth.HashPassword(request.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+
 	// Create user
-	if _, err := s.store.userRepo.Create(c, &user); err != nil {
+	user := &models.User{
+		Username:    request.Username,
+		DisplayName: request.DisplayName,
+		Password:    passwordHash,
+		Roles:       auth.DefaultRoles,
+	}
+	if _, err := s.store.userRepo.Create(c, user); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -133,66 +239,508 @@ func (s *Server) registerUserHandler(c *gin.Context) {
 }
 
 func (s *Server) authenticateUserHandler(c *gin.Context) {
-	var user models.User
-	if err := c.ShouldBindJSON(&user); err != nil {
+	var request credentials
+	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	existingUser, _ := s.store.userRepo.GetByUsername(c, user.Username)
+	// Locked out usernames are rejected the same way whether they exist or not
+	if s.lockedOut(c, request.Username) {
+		return
+	}
+
+	existingUser, _ := s.store.userRepo.GetByUsername(c, request.Username)
 	if existingUser == nil {
+		auth.SimulatePasswordCheck(request.Password)
+		s.recordFailedLogin(c, request.Username, models.LoginFailureInvalidCredentials)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
 		return
 	}
 
-	if existingUser.Password != user.Password {
+	match, needsRehash := auth.CheckPassword(existingUser.Password, request.Password)
+	if !match {
+		s.recordFailedLogin(c, request.Username, models.LoginFailureInvalidCredentials)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
 		return
 	}
 
-	// Generate JWT token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub":      existingUser.ID,
-		"username": user.Username,
-		"exp":      time.Now().Add(time.Minute * 15).Unix(),
+	s.loginSucceeded(c, request.Username)
+
+	// Replace passwords stored before hashing was introduced
+	if needsRehash {
+		if passwordHash, err := auth.HashPassword(request.Password); err == nil {
+			if err := s.store.userRepo.UpdatePassword(c, existingUser.ID, passwordHash); err != nil {
+				log.Printf("Warning: failed to rehash password of user %d: %v", existingUser.ID, err)
+			}
+		}
+	}
+
+	tokens, err := s.issueTokens(c, existingUser)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+func (s *Server) refreshTokenHandler(c *gin.Context) {
+	var request refreshTokenRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	existingToken, err := s.store.refreshTokenRepo.GetByHash(c, auth.HashToken(request.RefreshToken))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if existingToken == nil || time.Now().After(existingToken.ExpiresAt) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		return
+	}
+
+	// A revoked token being presented again means it leaked, so end every session of the user
+	if existingToken.RevokedAt != nil {
+		if err := s.store.refreshTokenRepo.RevokeAllForUser(c, existingToken.UserID); err != nil {
+			log.Printf("Warning: failed to revoke refresh tokens of user %d: %v", existingToken.UserID, err)
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		return
+	}
+
+	user, err := s.store.userRepo.GetByID(c, existingToken.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		return
+	}
+
+	refreshToken, replacement, err := s.newRefreshToken(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	rotated, err := s.store.refreshTokenRepo.Rotate(c, existingToken, replacement)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !rotated {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		return
+	}
+
+	tokens, err := s.tokenResponse(user, refreshToken)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+func (s *Server) logoutHandler(c *gin.Context) {
+	var request logoutRequest
+	if err := c.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := currentUser(c)
+
+	if request.All {
+		if err := s.store.refreshTokenRepo.RevokeAllForUser(c, user.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	} else if request.RefreshToken != "" {
+		existingToken, err := s.store.refreshTokenRepo.GetByHash(c, auth.HashToken(request.RefreshToken))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		// Tokens of other users are ignored rather than reported, so they can't be probed
+		if existingToken != nil && existingToken.UserID == user.ID {
+			if err := s.store.refreshTokenRepo.Revoke(c, existingToken.ID); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+	}
+
+	if err := s.revokeAccessToken(c, currentClaims(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (s *Server) siweNonceHandler(c *gin.Context) {
+	nonce, err := auth.NewSIWENonce()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	siweNonce, err := s.store.siweNonceRepo.Create(c, &models.SIWENonce{
+		Nonce:     nonce,
+		ExpiresAt: time.Now().Add(siweNonceTTL),
 	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"nonce": siweNonce.Nonce, "domain": s.siweDomain, "expiresAt": siweNonce.ExpiresAt})
+}
+
+func (s *Server) siweLoginHandler(c *gin.Context) {
+	message := s.verifySIWE(c)
+	if message == nil {
+		return
+	}
+
+	address := message.Address.Hex()
+	wallet, err := s.store.walletRepo.GetByAddress(c, address)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user *models.User
+	if wallet != nil {
+		if user, err = s.store.userRepo.GetByID(c, wallet.UserID); err != nil || user == nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load user"})
+			return
+		}
+	} else {
+		// First sign-in of an unknown wallet registers a user without a password, named after the address
+		if existingUser, _ := s.store.userRepo.GetByUsername(c, address); existingUser != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "Username of the wallet is already taken"})
+			return
+		}
+
+		user = &models.User{Username: address, Roles: auth.DefaultRoles}
+		if _, err := s.store.walletRepo.Create(c, &models.Wallet{User: user, Address: address}); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
 
-	tokenString, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	tokens, err := s.issueTokens(c, user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"token": tokenString})
+	c.JSON(http.StatusOK, tokens)
 }
 
-func (s *Server) myUserHandler(c *gin.Context) {
-	// Get the token from the request
-	tokenString := c.GetHeader("Authorization")
-	if tokenString == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "No token provided"})
+func (s *Server) linkWalletHandler(c *gin.Context) {
+	message := s.verifySIWE(c)
+	if message == nil {
+		return
+	}
+
+	user := currentUser(c)
+	address := message.Address.Hex()
+	wallet, err := s.store.walletRepo.GetByAddress(c, address)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if wallet != nil {
+		if wallet.UserID != user.ID {
+			c.JSON(http.StatusConflict, gin.H{"error": "Wallet is linked to another user"})
+			return
+		}
+		c.JSON(http.StatusOK, wallet)
+		return
+	}
+
+	wallet, err = s.store.walletRepo.Create(c, &models.Wallet{UserID: user.ID, Address: address})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, wallet)
+}
+
+func (s *Server) getWalletsHandler(c *gin.Context) {
+	wallets, err := s.store.walletRepo.GetByUserID(c, currentUser(c).ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Validate the token
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return []byte(os.Getenv("JWT_SECRET")), nil
+	c.JSON(http.StatusOK, wallets)
+}
+
+func (s *Server) createAPIKeyHandler(c *gin.Context) {
+	var request apiKeyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := auth.ValidateScopes(request.Scopes); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expiresAt must be in the future"})
+		return
+	}
+
+	key, prefix, hash, err := auth.NewAPIKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	apiKey, err := s.store.apiKeyRepo.Create(c, &models.APIKey{
+		UserID:    currentUser(c).ID,
+		Name:      request.Name,
+		Prefix:    prefix,
+		KeyHash:   hash,
+		Scopes:    request.Scopes,
+		ExpiresAt: request.ExpiresAt,
 	})
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, APIKeyResponse{APIKey: apiKey, Key: key})
+}
+
+func (s *Server) getAPIKeysHandler(c *gin.Context) {
+	apiKeys, err := s.store.apiKeyRepo.GetByUserID(c, currentUser(c).ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, apiKeys)
+}
+
+func (s *Server) revokeAPIKeyHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return
+	}
+
+	revoked, err := s.store.apiKeyRepo.Revoke(c, currentUser(c).ID, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !revoked {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (s *Server) grantRoleHandler(c *gin.Context) {
+	var request roleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	s.updateRoles(c, id, request.Role, func(roles []string) []string {
+		if slices.Contains(roles, request.Role) {
+			return roles
+		}
+		return append(roles, request.Role)
+	})
+}
+
+func (s *Server) revokeRoleHandler(c *gin.Context) {
+	role := c.Param("role")
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	// Admins can't demote themselves, so there is always an admin left to undo mistakes.
+	// IDs are compared as numbers, since e.g. "01" refers to user 1 too.
+	if role == auth.RoleAdmin && id == currentUser(c).ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Admins cannot revoke their own admin role"})
+		return
+	}
+
+	s.updateRoles(c, id, role, func(roles []string) []string {
+		return slices.DeleteFunc(slices.Clone(roles), func(r string) bool { return r == role })
+	})
+}
+
+func (s *Server) changePasswordHandler(c *gin.Context) {
+	var request changePasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := currentUser(c)
+	if !s.confirmPassword(c, user, request.CurrentPassword) {
+		return
+	}
+	if err := auth.ValidatePassword(request.NewPassword, user.Username); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	passwordHash, err := auth.HashPassword(request.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+	if err := s.store.userRepo.UpdatePassword(c, user.ID, passwordHash); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// End the other sessions, which may be why the password is changed, and start a new one
+	if err := s.store.refreshTokenRepo.RevokeAllForUser(c, user.ID); err != nil {
+		log.Printf("Warning: failed to revoke refresh tokens of user %d: %v", user.ID, err)
+	}
+	if claims := currentClaims(c); claims != nil {
+		if err := s.revokeAccessToken(c, claims); err != nil {
+			log.Printf("Warning: failed to revoke access token of user %d: %v", user.ID, err)
+		}
+	}
+
+	tokens, err := s.issueTokens(c, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Get the username from the token
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok || !token.Valid {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+	c.JSON(http.StatusOK, tokens)
+}
+
+func (s *Server) updateProfileHandler(c *gin.Context) {
+	var request updateProfileRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	userId := claims["sub"].(float64)
+	user := currentUser(c)
+	if request.Username != nil && *request.Username != user.Username {
+		if err := auth.ValidateUsername(*request.Username); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		existingUser, err := s.store.userRepo.GetByUsername(c, *request.Username)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if existingUser != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "Username is already taken"})
+			return
+		}
+		user.Username = *request.Username
+	}
+	if request.DisplayName != nil {
+		user.DisplayName = *request.DisplayName
+	}
+
+	if err := s.store.userRepo.UpdateProfile(c, user.ID, user.Username, user.DisplayName); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func (s *Server) deleteAccountHandler(c *gin.Context) {
+	var request deleteAccountRequest
+	if err := c.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := currentUser(c)
+	if !s.confirmPassword(c, user, request.Password) {
+		return
+	}
+
+	if err := s.store.userRepo.Delete(c, user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-	userTransactionIds, err := s.store.userTransactionRepo.GetTransactionsByUserId(c, int(userId))
+	log.Printf("User %d deleted their account", user.ID)
+	c.Status(http.StatusNoContent)
+}
+
+func (s *Server) meHandler(c *gin.Context) {
+	user := currentUser(c)
+	filter, _ := c.MustGet("historyFilter").(repository.HistoryFilter)
+
+	wallets, err := s.store.walletRepo.GetByUserID(c, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	apiKeyCount, err := s.store.apiKeyRepo.CountActive(c, user.ID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	lookups, total, err := s.store.userTransactionRepo.GetHistory(c, user.ID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	history := HistoryResponse{Items: make([]HistoryEntry, len(lookups)), Total: total, Limit: filter.Limit, Offset: filter.Offset}
+	for i, lookup := range lookups {
+		history.Items[i] = HistoryEntry{TransactionHash: lookup.TransactionHash, LookedUpAt: lookup.CreatedAt}
+	}
+
+	if wallets == nil {
+		wallets = []*models.Wallet{}
+	}
+
+	c.JSON(http.StatusOK, ProfileResponse{
+		ID:          user.ID,
+		Username:    user.Username,
+		DisplayName: user.DisplayName,
+		Roles:       user.Roles,
+		CreatedAt:   user.CreatedAt,
+		Wallets:     wallets,
+		APIKeyCount: apiKeyCount,
+		History:     history,
+	})
+}
+
+func (s *Server) myUserHandler(c *gin.Context) {
+	user := currentUser(c)
+
+	userTransactionIds, err := s.store.userTransactionRepo.GetTransactionsByUserId(c, user.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -209,26 +757,84 @@ func (s *Server) myUserHandler(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, transactions)
+	c.JSON(http.StatusOK, s.transactionResponses(c, transactions))
 }
 
+// savePersonHandler signs and broadcasts a setPersonInfo transaction and responds with its job,
+// without waiting for it to be mined
 func (s *Server) savePersonHandler(c *gin.Context) {
-	personData, exists := c.Get("personData")
-	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Person data not found in context"})
+	data, _ := c.MustGet("personData").(personData)
+
+	job, err := s.submitSavePerson(c, currentUser(c), data)
+	if err != nil {
+		// Errors carry the node's or database's error text, which is logged but not returned
+		switch {
+		case errors.Is(err, chain.ErrFeeTooHigh):
+			log.Printf("Warning: rejected savePerson request: %v", err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Network fees are above the configured maximum, try again later"})
+		case errors.Is(err, chain.ErrExecutionReverted):
+			log.Printf("Warning: rejected savePerson request: %v", err)
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "The transaction would revert"})
+		case errors.Is(err, errEstimation):
+			log.Printf("Warning: failed to estimate savePerson transaction: %v", err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Failed to estimate the transaction cost, try again later"})
+		case errors.Is(err, errNoSigner):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Contract writes are not configured"})
+		case job == nil:
+			log.Printf("Error: failed to submit savePerson transaction: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit transaction"})
+		default:
+			// The broadcast error was logged when the job was marked as failed
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to broadcast transaction", "job": job})
+		}
 		return
 	}
 
-	data := personData.(struct {
-		Name string `json:"name"`
-		Age  int    `json:"age"`
-	})
+	c.Header("Location", fmt.Sprintf("/lime/savePerson/%d", job.ID))
+	c.JSON(http.StatusAccepted, job)
+}
+
+// getSavePersonHandler returns a savePerson job by its ID or transaction hash.
+// Only the owner of the job and admins can see it.
+func (s *Server) getSavePersonHandler(c *gin.Context) {
+	id := c.Param("id")
 
-	txResponse, err := savePersonToContract(c, data)
+	var job *models.OutgoingTransaction
+	var err error
+	if strings.HasPrefix(id, "0x") {
+		if err := validateHashes([]string{id}); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		job, err = s.store.outgoingRepo.GetByHash(c, id)
+	} else {
+		jobID, parseErr := strconv.Atoi(id)
+		if parseErr != nil || jobID <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+			return
+		}
+		job, err = s.store.outgoingRepo.GetByID(c, jobID)
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, txResponse)
+	user := currentUser(c)
+	if job == nil || job.Kind != models.OutgoingKindSavePerson || (job.UserID != user.ID && !auth.HasRole(user.Roles, auth.RoleAdmin)) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// speedUpOutgoingHandler rebroadcasts a pending outgoing transaction with bumped fees
+func (s *Server) speedUpOutgoingHandler(c *gin.Context) {
+	s.replaceOutgoing(c, false)
+}
+
+// cancelOutgoingHandler replaces a pending outgoing transaction with a 0-value transfer to the sender
+func (s *Server) cancelOutgoingHandler(c *gin.Context) {
+	s.replaceOutgoing(c, true)
 }
@@ -0,0 +1,420 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"ethereum-fetcher-go/internal/auth"
+	"ethereum-fetcher-go/internal/chain"
+	"ethereum-fetcher-go/internal/models"
+	"ethereum-fetcher-go/internal/repository"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gin-gonic/gin"
+)
+
+// fakeLoginAttemptRepo is a repository.LoginAttemptRepository keeping audit records in memory
+type fakeLoginAttemptRepo struct {
+	repository.LoginAttemptRepository
+	attempts []*models.LoginAttempt
+}
+
+func (r *fakeLoginAttemptRepo) Create(_ context.Context, attempt *models.LoginAttempt) error {
+	r.attempts = append(r.attempts, attempt)
+	return nil
+}
+
+func TestAuthenticateUserLockout(t *testing.T) {
+	passwordHash, err := auth.HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, _ := auth.NewHMACKeySet("secret")
+	tokens, _ := auth.NewTokenManager(keys, "issuer", "audience", time.Minute, time.Hour)
+	loginAttemptRepo := &fakeLoginAttemptRepo{}
+	policy := auth.LockoutPolicy{Threshold: 2, BaseDelay: time.Minute, MaxDelay: time.Hour, ResetAfter: time.Hour}
+
+	// The clock is frozen, so the time spent hashing passwords doesn't shorten the lockout
+	now := time.Now()
+	s := &Server{
+		tokens: tokens,
+		logins: auth.NewLoginLimiter(auth.NewMemoryAttemptStore(), policy, auth.DefaultIPPolicy, auth.WithClock(func() time.Time { return now })),
+		store: &Store{
+			userRepo:         &fakeUserRepo{users: map[int]*models.User{1: {ID: 1, Username: "alice", Password: passwordHash}}},
+			refreshTokenRepo: &fakeRefreshTokenRepo{},
+			loginAttemptRepo: loginAttemptRepo,
+		},
+	}
+	r := gin.New()
+	r.POST("/lime/authenticate", s.authenticateUserHandler)
+
+	login := func(username, password string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(credentials{Username: username, Password: password})
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/lime/authenticate", bytes.NewReader(body)))
+		return rr
+	}
+
+	// Unknown usernames and wrong passwords are indistinguishable, including their lockouts
+	for _, username := range []string{"alice", "mallory"} {
+		var responses []string
+		for range 3 {
+			rr := login(username, "wrong horse")
+			responses = append(responses, rr.Body.String())
+			if rr.Code == http.StatusTooManyRequests && rr.Header().Get("Retry-After") != "60" {
+				t.Errorf("%s: expected Retry-After of 60 seconds, got %q", username, rr.Header().Get("Retry-After"))
+			}
+		}
+		if responses[0] != `{"error":"Invalid username or password"}` || responses[1] != responses[0] ||
+			responses[2] != `{"error":"Too many failed login attempts, try again later"}` {
+			t.Errorf("%s: unexpected responses %v", username, responses)
+		}
+	}
+
+	// The right password is rejected too while locked out
+	if rr := login("alice", "correct horse"); rr.Code != http.StatusTooManyRequests {
+		t.Errorf("expected locked out login to be rejected, got status %d", rr.Code)
+	}
+
+	if len(loginAttemptRepo.attempts) != 7 {
+		t.Errorf("expected 7 audit records, got %d", len(loginAttemptRepo.attempts))
+	}
+}
+
+func TestFetchTransactionsHandler(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	recipient := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	newTx := func(value int64) *types.Transaction {
+		return signTestTx(t, key, &recipient, big.NewInt(value), nil)
+	}
+	stored, fetched, missing, failing, unsaved := newTx(1), newTx(2), newTx(3), newTx(4), newTx(5)
+
+	backend := &fakeBackend{
+		txs: map[common.Hash]*types.Transaction{fetched.Hash(): fetched, unsaved.Hash(): unsaved},
+		receipts: map[common.Hash]*types.Receipt{
+			fetched.Hash(): {Status: types.ReceiptStatusSuccessful, BlockNumber: big.NewInt(42)},
+			unsaved.Hash(): {Status: types.ReceiptStatusSuccessful, BlockNumber: big.NewInt(42)},
+		},
+		errs: map[common.Hash]error{failing.Hash(): errors.New("connection refused")},
+	}
+
+	newRouter := func() (*gin.Engine, *fakeTransactionRepo) {
+		repo := &fakeTransactionRepo{
+			created:    []*models.Transaction{newTransactionModel(stored, recipient, &types.Receipt{BlockNumber: big.NewInt(41)})},
+			failCreate: map[string]bool{unsaved.Hash().Hex(): true},
+		}
+		s := &Server{
+			eth:              &fakeChain{backend: backend},
+			fetchConcurrency: 2,
+			abis:             newABIRegistry(&fakeContractABIRepo{abis: make(map[string]*models.ContractABI)}),
+			store:            &Store{transactionRepo: repo, transactionLogRepo: &fakeTransactionLogRepo{}},
+		}
+		r := gin.New()
+		r.GET("/lime/eth", ValidateUnits(), ValidateTransactionHashes(), s.fetchTransactionsHandler)
+		return r, repo
+	}
+
+	fetch := func(r *gin.Engine, hashes ...string) (int, TransactionsResponse) {
+		query := url.Values{"transactionHashes": hashes}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/lime/eth?"+query.Encode(), nil))
+
+		var response TransactionsResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("failed to decode response %s: %v", rr.Body.String(), err)
+		}
+		return rr.Code, response
+	}
+
+	t.Run("all resolved", func(t *testing.T) {
+		r, repo := newRouter()
+
+		// The stored hash is requested in upper case, so it only matches once normalized
+		upper := "0x" + strings.ToUpper(stored.Hash().Hex()[2:])
+		code, response := fetch(r, fetched.Hash().Hex(), upper)
+		if code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", code)
+		}
+		if len(response.Errors) != 0 {
+			t.Errorf("expected no errors, got %+v", response.Errors)
+		}
+		if len(response.Transactions) != 2 ||
+			response.Transactions[0].TransactionHash != fetched.Hash().Hex() ||
+			response.Transactions[1].TransactionHash != stored.Hash().Hex() {
+			t.Errorf("expected the fetched and stored transactions in request order, got %+v", response.Transactions)
+		}
+		if len(repo.created) != 2 {
+			t.Errorf("expected only the fetched transaction to be stored, got %d transactions", len(repo.created))
+		}
+	})
+
+	t.Run("partial result", func(t *testing.T) {
+		r, _ := newRouter()
+
+		code, response := fetch(r, stored.Hash().Hex(), missing.Hash().Hex(), failing.Hash().Hex(), unsaved.Hash().Hex())
+		if code != http.StatusMultiStatus {
+			t.Fatalf("expected status 207, got %d", code)
+		}
+		if len(response.Transactions) != 1 || response.Transactions[0].TransactionHash != stored.Hash().Hex() {
+			t.Errorf("expected only the stored transaction, got %+v", response.Transactions)
+		}
+
+		wantReasons := map[string]string{
+			missing.Hash().Hex(): FetchErrorNotFound,
+			failing.Hash().Hex(): FetchErrorRPC,
+			unsaved.Hash().Hex(): FetchErrorPersistence,
+		}
+		if len(response.Errors) != len(wantReasons) {
+			t.Fatalf("expected %d errors, got %+v", len(wantReasons), response.Errors)
+		}
+		for _, fetchErr := range response.Errors {
+			if want := wantReasons[fetchErr.TransactionHash]; fetchErr.Reason != want {
+				t.Errorf("expected reason %q for %s, got %q", want, fetchErr.TransactionHash, fetchErr.Reason)
+			}
+			if strings.Contains(fetchErr.Message, "connection refused") || strings.Contains(fetchErr.Message, "constraint") {
+				t.Errorf("expected the cause not to be reported to the client, got %q", fetchErr.Message)
+			}
+		}
+	})
+}
+
+func TestTransactionLogsHandler(t *testing.T) {
+	txHash := common.HexToHash("0x01").Hex()
+	contract := common.HexToAddress("0xdAf5794A77d20f773969876ec7AD7b6Ee30727b4")
+	other := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	transferTopic := common.HexToHash("0xdd").Hex()
+	approvalTopic := common.HexToHash("0xee").Hex()
+
+	// The node knows the unknown transaction, but it is never fetched from the network here
+	key, _ := crypto.GenerateKey()
+	unknown := signTestTx(t, key, &other, big.NewInt(0), nil)
+	backend := &fakeBackend{txs: map[common.Hash]*types.Transaction{unknown.Hash(): unknown}}
+	s := &Server{
+		eth: &fakeChain{backend: backend},
+		store: &Store{
+			transactionRepo: &fakeTransactionRepo{created: []*models.Transaction{{TransactionHash: txHash}}},
+			transactionLogRepo: &fakeTransactionLogRepo{logs: []*models.TransactionLog{
+				{TransactionHash: txHash, LogIndex: 0, Address: contract.Hex(), Topic0: transferTopic},
+				{TransactionHash: txHash, LogIndex: 1, Address: contract.Hex(), Topic0: approvalTopic},
+				{TransactionHash: txHash, LogIndex: 2, Address: other.Hex(), Topic0: transferTopic},
+			}},
+		},
+	}
+	r := gin.New()
+	r.GET("/lime/eth/:rlphex/logs", ValidateTransactionLogsQuery(), s.transactionLogsHandler)
+
+	tests := []struct {
+		name        string
+		path        string
+		wantStatus  int
+		wantIndexes []uint
+	}{
+		{"all logs", "/lime/eth/" + txHash + "/logs", http.StatusOK, []uint{0, 1, 2}},
+		// Addresses match regardless of their case, since they are normalized to checksum form
+		{"by address", "/lime/eth/" + txHash + "/logs?address=" + strings.ToLower(contract.Hex()), http.StatusOK, []uint{0, 1}},
+		{"by topic0", "/lime/eth/" + txHash + "/logs?topic0=" + transferTopic, http.StatusOK, []uint{0, 2}},
+		{"by address and topic0", "/lime/eth/" + txHash + "/logs?address=" + contract.Hex() + "&topic0=" + approvalTopic, http.StatusOK, []uint{1}},
+		{"invalid hash", "/lime/eth/0x1234/logs", http.StatusBadRequest, nil},
+		{"invalid address", "/lime/eth/" + txHash + "/logs?address=0x1234", http.StatusBadRequest, nil},
+		{"invalid topic0", "/lime/eth/" + txHash + "/logs?topic0=transfer", http.StatusBadRequest, nil},
+		{"unknown transaction", "/lime/eth/" + unknown.Hash().Hex() + "/logs", http.StatusNotFound, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, rr.Code, rr.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var logs []models.TransactionLog
+			if err := json.Unmarshal(rr.Body.Bytes(), &logs); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			var indexes []uint
+			for _, transactionLog := range logs {
+				indexes = append(indexes, transactionLog.LogIndex)
+			}
+			if !slices.Equal(indexes, tt.wantIndexes) {
+				t.Errorf("expected logs %v, got %v", tt.wantIndexes, indexes)
+			}
+		})
+	}
+}
+
+func TestSavePersonHandlerEstimationErrors(t *testing.T) {
+	t.Setenv("CONTRACT_ADDRESS", "0xdAf5794A77d20f773969876ec7AD7b6Ee30727b4")
+	key, _ := crypto.GenerateKey()
+
+	tests := []struct {
+		name       string
+		gasErr     error
+		feeCaps    chain.FeeCaps
+		wantStatus int
+	}{
+		{"fees above the cap", nil, chain.FeeCaps{MaxFeePerGas: big.NewInt(50)}, http.StatusServiceUnavailable},
+		{"reverted", errors.New("execution reverted: age must be positive"), chain.FeeCaps{}, http.StatusUnprocessableEntity},
+		{"node unavailable", errors.New("dial tcp 10.0.0.1:8545: connection refused"), chain.FeeCaps{}, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{
+				eth:     &fakeChain{backend: &fakeBackend{}, gasErr: tt.gasErr},
+				signer:  chain.NewKeySigner(key),
+				feeCaps: tt.feeCaps,
+			}
+			r := gin.New()
+			r.POST("/lime/savePerson", func(c *gin.Context) {
+				c.Set(userContextKey, &models.User{ID: 1})
+				c.Set("personData", personData{Name: "Alice", Age: 30, FeePolicy: chain.FeePolicyStandard})
+			}, s.savePersonHandler)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/lime/savePerson", nil))
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, rr.Code, rr.Body.String())
+			}
+			for _, detail := range []string{"wei", "execution reverted", "connection refused"} {
+				if strings.Contains(rr.Body.String(), detail) {
+					t.Errorf("expected the node's error not to be returned, got %s", rr.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestSavePersonHandlerSubmitErrors(t *testing.T) {
+	t.Setenv("CONTRACT_ADDRESS", "0xdAf5794A77d20f773969876ec7AD7b6Ee30727b4")
+	key, _ := crypto.GenerateKey()
+
+	tests := []struct {
+		name       string
+		signer     chain.Signer
+		sendErr    error
+		wantStatus int
+	}{
+		{"no signer", nil, nil, http.StatusServiceUnavailable},
+		{"broadcast rejected", chain.NewKeySigner(key), errors.New("insufficient funds for gas * price + value: address 0x01 have 0 want 1000"), http.StatusBadGateway},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eth := &fakeChain{backend: &fakeBackend{}, sendErr: tt.sendErr}
+			outgoingRepo := &fakeOutgoingRepo{}
+			s := &Server{
+				eth:    eth,
+				signer: tt.signer,
+				nonces: chain.NewNonceManager(eth, nonceStore{
+					repo:         &fakeAccountNonceRepo{nonces: make(map[string]*models.AccountNonce)},
+					outgoingRepo: outgoingRepo,
+				}),
+				store: &Store{outgoingRepo: outgoingRepo},
+			}
+			r := gin.New()
+			r.POST("/lime/savePerson", func(c *gin.Context) {
+				c.Set(userContextKey, &models.User{ID: 1})
+				c.Set("personData", personData{Name: "Alice", Age: 30, FeePolicy: chain.FeePolicyStandard})
+			}, s.savePersonHandler)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/lime/savePerson", nil))
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, rr.Code, rr.Body.String())
+			}
+			for _, detail := range []string{"insufficient funds", "no signer"} {
+				if strings.Contains(rr.Body.String(), detail) {
+					t.Errorf("expected the underlying error not to be returned, got %s", rr.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestGetAllTransactionsHandler(t *testing.T) {
+	repo := &fakeTransactionRepo{}
+	for i := range 5 {
+		repo.created = append(repo.created, &models.Transaction{TransactionHash: common.BigToHash(big.NewInt(int64(i + 1))).Hex()})
+	}
+	s := &Server{
+		abis:  newABIRegistry(&fakeContractABIRepo{abis: make(map[string]*models.ContractABI)}),
+		store: &Store{transactionRepo: repo, transactionLogRepo: &fakeTransactionLogRepo{}},
+	}
+	r := gin.New()
+	r.GET("/lime/all", ValidatePage(), ValidateUnits(), s.getAllTransactionsHandler)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/lime/all"+query, nil))
+		return rr
+	}
+
+	rr := get("?limit=2&offset=3")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var response TransactionPage
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Total != 5 || response.Limit != 2 || response.Offset != 3 || len(response.Items) != 2 ||
+		response.Items[0].TransactionHash != repo.created[3].TransactionHash {
+		t.Errorf("expected the 4th and 5th of 5 transactions, got %+v", response)
+	}
+
+	for _, query := range []string{"?limit=0", "?limit=101", "?offset=-1", "?limit=ten"} {
+		if rr := get(query); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, rr.Code)
+		}
+	}
+}
+
+func TestRevokeOwnAdminRole(t *testing.T) {
+	admin := &models.User{ID: 1, Username: "alice", Roles: []string{auth.RoleAdmin}}
+	other := &models.User{ID: 2, Username: "bob", Roles: []string{auth.RoleAdmin}}
+	s := &Server{store: &Store{userRepo: &fakeUserRepo{users: map[int]*models.User{1: admin, 2: other}}}}
+	r := gin.New()
+	r.DELETE("/lime/admin/users/:id/roles/:role", func(c *gin.Context) { c.Set(userContextKey, admin) }, s.revokeRoleHandler)
+
+	revoke := func(id string) int {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodDelete, "/lime/admin/users/"+id+"/roles/"+auth.RoleAdmin, nil))
+		return rr.Code
+	}
+
+	// Spellings of the admin's own ID are rejected as well
+	for _, id := range []string{"1", "01", "+1"} {
+		if code := revoke(id); code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", id, code)
+		}
+	}
+	if code := revoke("one"); code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an invalid ID, got %d", code)
+	}
+	if !slices.Contains(admin.Roles, auth.RoleAdmin) {
+		t.Fatal("expected the admin to keep their admin role")
+	}
+
+	if code := revoke("2"); code != http.StatusOK || slices.Contains(other.Roles, auth.RoleAdmin) {
+		t.Errorf("expected the admin role of another user to be revoked, got status %d and roles %v", code, other.Roles)
+	}
+}
@@ -2,15 +2,26 @@ package server
 
 import (
 	"encoding/hex"
+	"ethereum-fetcher-go/internal/chain"
+	"ethereum-fetcher-go/internal/models"
+	"ethereum-fetcher-go/internal/repository"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	// defaultHistoryLimit and maxHistoryLimit bound the page size of the lookup history and other lists
+	defaultHistoryLimit = 20
+	maxHistoryLimit     = 100
+)
+
 func validateHashes(hashes []string) error {
 	if len(hashes) == 0 {
 		return fmt.Errorf("transactionHashes parameter is required")
@@ -47,6 +58,11 @@ func ValidateTransactionHashes() gin.HandlerFunc {
 			return
 		}
 
+		// Stored hashes are lowercase, so hashes sent in another case still match them
+		for i, hash := range hashes {
+			hashes[i] = strings.ToLower(hash)
+		}
+
 		// Store validated hashes in context for handler
 		c.Set("validatedHashes", hashes)
 		c.Next()
@@ -94,29 +110,164 @@ func ValidateRlpHex() gin.HandlerFunc {
 	}
 }
 
+// ValidateTransactionLogsQuery validates the transaction hash and the optional
+// address and topic0 filters of a transaction logs request
+func ValidateTransactionLogsQuery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// gin requires the wildcard to be named like the one of the /lime/eth/:rlphex route
+		hash := c.Param("rlphex")
+		if err := validateHashes([]string{hash}); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var filter repository.LogFilter
+
+		if address := c.Query("address"); address != "" {
+			if !common.IsHexAddress(address) {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid address: " + address})
+				return
+			}
+			filter.Address = common.HexToAddress(address).Hex()
+		}
+
+		if topic0 := c.Query("topic0"); topic0 != "" {
+			if err := validateHashes([]string{topic0}); err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid topic0: " + topic0})
+				return
+			}
+			filter.Topic0 = common.HexToHash(topic0).Hex()
+		}
+
+		// Store the normalized hash and filter in context for handler
+		c.Set("transactionHash", common.HexToHash(hash).Hex())
+		c.Set("logFilter", filter)
+		c.Next()
+	}
+}
+
+// page is the requested page of a list endpoint
+type page struct {
+	Limit  int
+	Offset int
+}
+
+// parsePage parses the optional limit and offset query parameters of a list endpoint
+func parsePage(c *gin.Context) (page, error) {
+	p := page{Limit: defaultHistoryLimit}
+
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxHistoryLimit {
+			return page{}, fmt.Errorf("limit must be between 1 and %d", maxHistoryLimit)
+		}
+		p.Limit = n
+	}
+
+	if offset := c.Query("offset"); offset != "" {
+		n, err := strconv.Atoi(offset)
+		if err != nil || n < 0 {
+			return page{}, fmt.Errorf("offset must be a non-negative integer")
+		}
+		p.Offset = n
+	}
+
+	return p, nil
+}
+
+// ValidatePage validates the pagination of a list endpoint
+func ValidatePage() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, err := parsePage(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Set("page", p)
+		c.Next()
+	}
+}
+
+// ValidateHistoryQuery validates the pagination and the optional since, until and status
+// filters of a lookup history request
+func ValidateHistoryQuery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, err := parsePage(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		filter := repository.HistoryFilter{Limit: page.Limit, Offset: page.Offset}
+
+		for name, target := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+			if value := c.Query(name); value != "" {
+				t, err := time.Parse(time.RFC3339, value)
+				if err != nil {
+					c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": name + " must be an RFC 3339 timestamp"})
+					return
+				}
+				*target = t
+			}
+		}
+
+		if status := c.Query("status"); status != "" {
+			switch status {
+			case models.TransactionStatusPending, models.TransactionStatusMined, models.TransactionStatusDropped, models.TransactionStatusReplaced:
+				filter.Status = status
+			default:
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid status: " + status})
+				return
+			}
+		}
+
+		c.Set("historyFilter", filter)
+		c.Next()
+	}
+}
+
+// ValidateUnits validates the optional units query parameter used to format wei amounts
+func ValidateUnits() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		units := c.DefaultQuery("units", "wei")
+
+		if _, ok := unitDecimals[units]; !ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "units must be one of ether, gwei or wei"})
+			return
+		}
+
+		c.Set("units", units)
+		c.Next()
+	}
+}
+
 func ValidatePersonData() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var personData struct {
-			Name string `json:"name"`
-			Age  int    `json:"age"`
+		var data personData
+
+		if err := c.ShouldBindJSON(&data); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
 		}
 
-		if err := c.ShouldBindJSON(&personData); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if data.Age < 0 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Age cannot be negative"})
 			return
 		}
 
-		if personData.Age < 0 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Age cannot be negative"})
+		if data.Name == "" {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Name cannot be empty"})
 			return
 		}
 
-		if personData.Name == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Name cannot be empty"})
+		policy, err := chain.ParseFeePolicy(string(data.FeePolicy))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		data.FeePolicy = policy
 
-		c.Set("personData", personData)
+		c.Set("personData", data)
 		c.Next()
 	}
 }
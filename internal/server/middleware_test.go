@@ -0,0 +1,58 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ethereum-fetcher-go/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestValidateHistoryQuery(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantFilter repository.HistoryFilter
+	}{
+		{"defaults", "", http.StatusOK, repository.HistoryFilter{Limit: defaultHistoryLimit}},
+		{
+			"all filters",
+			"?limit=5&offset=10&since=2025-01-01T00:00:00Z&until=2025-02-01T00:00:00Z&status=mined",
+			http.StatusOK,
+			repository.HistoryFilter{
+				Limit:  5,
+				Offset: 10,
+				Since:  time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+				Until:  time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+				Status: "mined",
+			},
+		},
+		{"limit too large", "?limit=1000", http.StatusBadRequest, repository.HistoryFilter{}},
+		{"negative offset", "?offset=-1", http.StatusBadRequest, repository.HistoryFilter{}},
+		{"malformed since", "?since=yesterday", http.StatusBadRequest, repository.HistoryFilter{}},
+		{"unknown status", "?status=lost", http.StatusBadRequest, repository.HistoryFilter{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var filter repository.HistoryFilter
+			r := gin.New()
+			r.GET("/lime/me", ValidateHistoryQuery(), func(c *gin.Context) {
+				filter, _ = c.MustGet("historyFilter").(repository.HistoryFilter)
+			})
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/lime/me"+tt.query, nil))
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, rr.Code, rr.Body.String())
+			}
+			if tt.wantStatus == http.StatusOK && filter != tt.wantFilter {
+				t.Errorf("expected filter %+v, got %+v", tt.wantFilter, filter)
+			}
+		})
+	}
+}
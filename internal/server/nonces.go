@@ -0,0 +1,48 @@
+package server
+
+import (
+	"context"
+	"log"
+
+	"ethereum-fetcher-go/internal/models"
+	"ethereum-fetcher-go/internal/repository"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// nonceStore is a chain.NonceStore backed by the account nonce and outgoing transaction repositories
+type nonceStore struct {
+	repo         repository.AccountNonceRepository
+	outgoingRepo repository.OutgoingTransactionRepository
+}
+
+// GetNonce implements chain.NonceStore
+func (s nonceStore) GetNonce(ctx context.Context, account common.Address) (uint64, bool, error) {
+	nonce, err := s.repo.GetByAddress(ctx, account.Hex())
+	if err != nil || nonce == nil {
+		return 0, false, err
+	}
+	return nonce.NextNonce, true, nil
+}
+
+// PutNonce implements chain.NonceStore
+func (s nonceStore) PutNonce(ctx context.Context, account common.Address, next uint64) error {
+	return s.repo.Upsert(ctx, &models.AccountNonce{Address: account.Hex(), NextNonce: next})
+}
+
+// HasPending implements chain.NonceStore
+func (s nonceStore) HasPending(ctx context.Context, account common.Address, nonce uint64) (bool, error) {
+	return s.outgoingRepo.HasPendingFrom(ctx, account.Hex(), nonce)
+}
+
+// syncNonces resyncs the nonce of the signing account with the chain, so transactions
+// sent before a restart or by other means are accounted for
+func (s *Server) syncNonces(ctx context.Context) {
+	if s.signer == nil {
+		return
+	}
+
+	if err := s.nonces.Sync(ctx, s.signer.Address()); err != nil {
+		log.Printf("Warning: failed to sync nonce: %v", err)
+	}
+}
@@ -0,0 +1,635 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"ethereum-fetcher-go/internal/chain"
+	"ethereum-fetcher-go/internal/contracts"
+	"ethereum-fetcher-go/internal/models"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const (
+	// submitTimeout bounds signing and broadcasting a transaction, independent of the client connection
+	submitTimeout = 20 * time.Second
+
+	// queuedTimeout is how long a job may stay queued before it is considered never broadcast
+	queuedTimeout = time.Minute
+
+	// defaultStuckTimeout is how long a transaction may stay pending before it is rebroadcast with bumped fees
+	defaultStuckTimeout = 5 * time.Minute
+
+	// defaultMaxFeeBumps is used when MAX_FEE_BUMPS is not set
+	defaultMaxFeeBumps = 5
+
+	// defaultMaxFeePerGasGwei and defaultMaxPriorityFeePerGasGwei are used when MAX_FEE_PER_GAS_GWEI
+	// and MAX_PRIORITY_FEE_PER_GAS_GWEI are not set, so speed-ups never raise fees without bound
+	defaultMaxFeePerGasGwei         = "100"
+	defaultMaxPriorityFeePerGasGwei = "5"
+)
+
+var (
+	// errNoSigner is returned for contract writes when no signer is configured
+	errNoSigner = errors.New("no signer configured")
+
+	// errNotPending is returned when replacing a transaction that is no longer pending
+	errNotPending = errors.New("transaction is not pending")
+
+	// errEstimation is returned when the gas or fees of a transaction can't be estimated
+	errEstimation = errors.New("failed to estimate transaction cost")
+)
+
+// personData is the validated payload of the savePerson endpoint
+type personData struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+	// FeePolicy is slow, standard or fast, defaulting to standard
+	FeePolicy chain.FeePolicy `json:"feePolicy"`
+}
+
+// newSigner creates the signer of the server's account selected by SIGNER_TYPE.
+// It returns nil if the default env signer has no PRIVATE_KEY, which disables contract writes.
+func newSigner(ctx context.Context) (chain.Signer, error) {
+	switch signerType := envOrDefault("SIGNER_TYPE", "env"); signerType {
+	case "env":
+		if os.Getenv("PRIVATE_KEY") == "" {
+			return nil, nil
+		}
+		signer, err := chain.NewHexKeySigner(os.Getenv("PRIVATE_KEY"))
+		if err != nil {
+			return nil, err
+		}
+		return signer, nil
+	case "keystore":
+		signer, err := chain.NewKeystoreSigner(os.Getenv("SIGNER_KEYSTORE_FILE"), os.Getenv("SIGNER_PASSWORD_FILE"))
+		if err != nil {
+			return nil, err
+		}
+		return signer, nil
+	case "remote":
+		address := os.Getenv("SIGNER_ADDRESS")
+		if !common.IsHexAddress(address) {
+			return nil, fmt.Errorf("invalid SIGNER_ADDRESS %q", address)
+		}
+		signer, err := chain.NewRemoteSigner(ctx, os.Getenv("SIGNER_URL"), common.HexToAddress(address))
+		if err != nil {
+			return nil, err
+		}
+		return signer, nil
+	default:
+		return nil, fmt.Errorf("unknown SIGNER_TYPE %q, must be one of env, keystore, remote", signerType)
+	}
+}
+
+// submitSavePerson stores a setPersonInfo transaction as a job, then signs and broadcasts it
+// without waiting for it to be mined. The job is returned along with any broadcast error,
+// so clients can still look it up.
+func (s *Server) submitSavePerson(ctx context.Context, user *models.User, data personData) (*models.OutgoingTransaction, error) {
+	contractAddress := os.Getenv("CONTRACT_ADDRESS")
+	if !common.IsHexAddress(contractAddress) || common.HexToAddress(contractAddress) == (common.Address{}) {
+		return nil, errors.New("invalid contract address")
+	}
+
+	if s.signer == nil {
+		return nil, errNoSigner
+	}
+
+	contractABI, err := contracts.ContractsMetaData.GetAbi()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse contract ABI: %w", err)
+	}
+	input, err := contractABI.Pack("setPersonInfo", data.Name, big.NewInt(int64(data.Age)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode setPersonInfo call: %w", err)
+	}
+
+	// Fees and gas are estimated before the job is created, so rejected requests leave no job behind
+	from := s.signer.Address()
+	to := common.HexToAddress(contractAddress)
+	gasLimit, err := chain.EstimateGasLimit(ctx, s.eth, ethereum.CallMsg{From: from, To: &to, Data: input})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errEstimation, err)
+	}
+	fees, err := chain.EstimateFees(ctx, s.eth, data.FeePolicy, s.feeCaps)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errEstimation, err)
+	}
+
+	job, err := s.store.outgoingRepo.Create(ctx, &models.OutgoingTransaction{
+		UserID:               user.ID,
+		Kind:                 models.OutgoingKindSavePerson,
+		Status:               models.OutgoingStatusQueued,
+		From:                 from.Hex(),
+		To:                   to.Hex(),
+		Input:                hexutil.Encode(input),
+		GasLimit:             gasLimit,
+		FeePolicy:            string(data.FeePolicy),
+		MaxFeePerGas:         models.NewBigInt(fees.GasFeeCap),
+		MaxPriorityFeePerGas: models.NewBigInt(fees.GasTipCap),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store transaction: %w", err)
+	}
+
+	// Broadcasting must not be abandoned halfway because the client went away
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), submitTimeout)
+	defer cancel()
+
+	if err := s.broadcast(ctx, job); err != nil {
+		// The job is returned to clients, so it keeps a fixed message instead of the node's error
+		log.Printf("Warning: failed to broadcast outgoing transaction %d: %v", job.ID, err)
+		job.Status = models.OutgoingStatusFailed
+		job.Error = "transaction could not be broadcast"
+		if updateErr := s.store.outgoingRepo.Update(ctx, job); updateErr != nil {
+			log.Printf("Warning: failed to update outgoing transaction %d: %v", job.ID, updateErr)
+		}
+		return job, err
+	}
+
+	return job, nil
+}
+
+// jobTx returns the unsigned transaction of a job with its current nonce and fees.
+// Cancelling jobs send nothing to their sender instead of their call.
+func jobTx(job *models.OutgoingTransaction, chainID *big.Int) (*types.DynamicFeeTx, error) {
+	tx := &types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     job.Nonce,
+		GasTipCap: new(big.Int).Set(&job.MaxPriorityFeePerGas.Int),
+		GasFeeCap: new(big.Int).Set(&job.MaxFeePerGas.Int),
+		Value:     big.NewInt(0),
+	}
+
+	if job.Status == models.OutgoingStatusCancelling {
+		to := common.HexToAddress(job.From)
+		tx.To = &to
+		tx.Gas = params.TxGas
+		return tx, nil
+	}
+
+	input, err := hexutil.Decode(job.Input)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction input: %w", err)
+	}
+	to := common.HexToAddress(job.To)
+	tx.To = &to
+	tx.Gas = job.GasLimit
+	tx.Data = input
+	return tx, nil
+}
+
+// broadcast signs the job's transaction with its estimated fees and sends it to the network.
+// The hash is stored before sending, so a transaction that was sent before a crash can still
+// be found by the watcher.
+func (s *Server) broadcast(ctx context.Context, job *models.OutgoingTransaction) error {
+	chainID, err := s.eth.ChainID(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get chain ID: %w", err)
+	}
+
+	// The nonce is allocated last, so it is held as briefly as possible
+	nonce, err := s.nonces.Next(ctx, common.HexToAddress(job.From))
+	if err != nil {
+		return fmt.Errorf("failed to allocate nonce: %w", err)
+	}
+	return s.sendJob(ctx, job, chainID, nonce)
+}
+
+// sendJob signs the job's transaction with an allocated nonce and sends it, reporting the
+// outcome of the nonce to the nonce manager
+func (s *Server) sendJob(ctx context.Context, job *models.OutgoingTransaction, chainID *big.Int, nonce uint64) error {
+	from := common.HexToAddress(job.From)
+	job.Nonce = nonce
+
+	tx, err := jobTx(job, chainID)
+	if err != nil {
+		s.releaseNonce(ctx, from, nonce, err)
+		return err
+	}
+	signed, err := s.signer.SignTx(ctx, types.NewTx(tx), chainID)
+	if err != nil {
+		s.releaseNonce(ctx, from, nonce, err)
+		return fmt.Errorf("failed to sign transaction: %w", err)
+	}
+
+	job.TransactionHash = signed.Hash().Hex()
+	if err := s.store.outgoingRepo.Update(ctx, job); err != nil {
+		s.releaseNonce(ctx, from, nonce, err)
+		return fmt.Errorf("failed to store transaction: %w", err)
+	}
+
+	if err := s.eth.SendTransaction(ctx, signed); err != nil {
+		if chain.IsRejected(err) {
+			s.releaseNonce(ctx, from, nonce, err)
+			return fmt.Errorf("failed to send transaction: %w", err)
+		}
+		// The node may have received the transaction anyway, so its nonce is kept
+		// and the watcher settles it, rebroadcasting it if it gets stuck
+		log.Printf("Warning: outgoing transaction %d may not have been sent: %v", job.ID, err)
+	}
+	s.nonces.Done(from, nonce)
+
+	now := time.Now()
+	job.Status = models.OutgoingStatusBroadcast
+	job.BroadcastAt = &now
+	if err := s.store.outgoingRepo.Update(ctx, job); err != nil {
+		log.Printf("Warning: failed to mark outgoing transaction %d as broadcast: %v", job.ID, err)
+	}
+	return nil
+}
+
+// releaseNonce returns the nonce of a transaction that was never sent or was rejected, so it
+// doesn't leave a gap. If the network rejected the nonce as used, the nonce is resynced instead.
+func (s *Server) releaseNonce(ctx context.Context, from common.Address, nonce uint64, cause error) {
+	ctx = context.WithoutCancel(ctx)
+
+	if chain.IsNonceTooLow(cause) {
+		s.nonces.Done(from, nonce)
+		if err := s.nonces.Sync(ctx, from); err != nil {
+			log.Printf("Warning: failed to sync nonce: %v", err)
+		}
+		return
+	}
+
+	if err := s.nonces.Release(ctx, from, nonce); err != nil {
+		log.Printf("Warning: failed to release nonce %d: %v", nonce, err)
+	}
+}
+
+// replace rebroadcasts a pending job with bumped fees and the same nonce, so the replacement
+// is mined instead of the original. With cancel, the replacement is a 0-value transfer to the
+// sender, so the original call never executes. Cancelled jobs are always replaced by a cancel.
+// The caller must hold the job's lock and have loaded the job under it.
+func (s *Server) replace(ctx context.Context, job *models.OutgoingTransaction, cancel bool) error {
+	if job.Status != models.OutgoingStatusBroadcast && job.Status != models.OutgoingStatusCancelling {
+		return errNotPending
+	}
+
+	if s.signer == nil {
+		return errNoSigner
+	}
+	if s.signer.Address() != common.HexToAddress(job.From) {
+		return errors.New("transaction was signed by another account")
+	}
+
+	policy, err := chain.ParseFeePolicy(job.FeePolicy)
+	if err != nil {
+		return err
+	}
+	fresh, err := chain.EstimateFees(ctx, s.eth, policy, chain.FeeCaps{})
+	if err != nil {
+		return err
+	}
+	current := &chain.Fees{GasTipCap: &job.MaxPriorityFeePerGas.Int, GasFeeCap: &job.MaxFeePerGas.Int}
+	fees, err := chain.BumpFees(current, fresh, s.feeCaps)
+	if err != nil {
+		return err
+	}
+
+	chainID, err := s.eth.ChainID(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get chain ID: %w", err)
+	}
+
+	// The job is stored before sending like in broadcast, and restored if the network rejects it
+	previous := *job
+	previous.PreviousHashes = slices.Clone(job.PreviousHashes)
+
+	job.PreviousHashes = append(job.PreviousHashes, job.TransactionHash)
+	job.MaxFeePerGas = models.NewBigInt(fees.GasFeeCap)
+	job.MaxPriorityFeePerGas = models.NewBigInt(fees.GasTipCap)
+	if cancel {
+		job.Status = models.OutgoingStatusCancelling
+	}
+
+	tx, err := jobTx(job, chainID)
+	if err != nil {
+		*job = previous
+		return err
+	}
+	signed, err := s.signer.SignTx(ctx, types.NewTx(tx), chainID)
+	if err != nil {
+		*job = previous
+		return fmt.Errorf("failed to sign transaction: %w", err)
+	}
+
+	now := time.Now()
+	job.TransactionHash = signed.Hash().Hex()
+	job.BroadcastAt = &now
+	if err := s.store.outgoingRepo.Update(ctx, job); err != nil {
+		*job = previous
+		return fmt.Errorf("failed to store transaction: %w", err)
+	}
+
+	if err := s.eth.SendTransaction(ctx, signed); err != nil {
+		if !chain.IsRejected(err) {
+			// The node may have received the replacement anyway, so it is tracked like a sent one
+			log.Printf("Warning: replacement of outgoing transaction %d may not have been sent: %v", job.ID, err)
+			return nil
+		}
+
+		// The attempted hash stays among the previous ones, so the watcher still checks it
+		attempted := job.TransactionHash
+		*job = previous
+		job.PreviousHashes = append(job.PreviousHashes, attempted)
+		if updateErr := s.store.outgoingRepo.Update(ctx, job); updateErr != nil {
+			log.Printf("Warning: failed to restore outgoing transaction %d: %v", job.ID, updateErr)
+		}
+		return fmt.Errorf("failed to send transaction: %w", err)
+	}
+	return nil
+}
+
+// fillNonceGaps sends a 0-value transfer to the signing account for each of its released nonces.
+// A nonce released below the next one is reused by the next transaction, but until one is sent,
+// the transactions after the gap can't be mined.
+func (s *Server) fillNonceGaps(ctx context.Context) {
+	if s.signer == nil {
+		return
+	}
+
+	from := s.signer.Address()
+	nonces, err := s.nonces.TakeReleased(ctx, from)
+	if err != nil {
+		log.Printf("Warning: failed to load released nonces: %v", err)
+		return
+	}
+	for _, nonce := range nonces {
+		if err := s.fillNonce(ctx, from, nonce); err != nil {
+			log.Printf("Warning: failed to fill nonce %d: %v", nonce, err)
+		}
+	}
+}
+
+// fillNonce stores and sends a 0-value transfer to the account with an allocated nonce.
+// It is tracked as a job of no user, so the watcher speeds it up if it gets stuck.
+func (s *Server) fillNonce(ctx context.Context, from common.Address, nonce uint64) error {
+	fees, err := chain.EstimateFees(ctx, s.eth, chain.FeePolicyStandard, s.feeCaps)
+	if err != nil {
+		s.releaseNonce(ctx, from, nonce, err)
+		return err
+	}
+	chainID, err := s.eth.ChainID(ctx)
+	if err != nil {
+		s.releaseNonce(ctx, from, nonce, err)
+		return fmt.Errorf("failed to get chain ID: %w", err)
+	}
+
+	job, err := s.store.outgoingRepo.Create(ctx, &models.OutgoingTransaction{
+		Kind:                 models.OutgoingKindFillNonce,
+		Status:               models.OutgoingStatusQueued,
+		From:                 from.Hex(),
+		To:                   from.Hex(),
+		Input:                "0x",
+		GasLimit:             params.TxGas,
+		FeePolicy:            string(chain.FeePolicyStandard),
+		MaxFeePerGas:         models.NewBigInt(fees.GasFeeCap),
+		MaxPriorityFeePerGas: models.NewBigInt(fees.GasTipCap),
+	})
+	if err != nil {
+		s.releaseNonce(ctx, from, nonce, err)
+		return fmt.Errorf("failed to store transaction: %w", err)
+	}
+
+	if err := s.sendJob(ctx, job, chainID, nonce); err != nil {
+		job.Status = models.OutgoingStatusFailed
+		job.Error = "transaction could not be broadcast"
+		if updateErr := s.store.outgoingRepo.Update(ctx, job); updateErr != nil {
+			log.Printf("Warning: failed to update outgoing transaction %d: %v", job.ID, updateErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// jobLocks serializes changes to outgoing transactions by job ID, so concurrent replacements
+// don't overwrite each other's transaction hashes. The zero value is ready to use.
+type jobLocks struct {
+	mu    sync.Mutex
+	locks map[int]*jobLock
+}
+
+// jobLock is the lock of a single job, removed once nobody holds or waits for it
+type jobLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lock locks the job with the given ID and returns the function unlocking it
+func (l *jobLocks) lock(id int) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[int]*jobLock)
+	}
+	lock, ok := l.locks[id]
+	if !ok {
+		lock = &jobLock{}
+		l.locks[id] = lock
+	}
+	lock.refs++
+	l.mu.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if lock.refs--; lock.refs == 0 {
+			delete(l.locks, id)
+		}
+	}
+}
+
+// runOutgoingWatcher periodically tracks broadcast transactions until they are mined,
+// failed or replaced, speeds up transactions that are stuck, and fills nonce gaps,
+// until ctx is cancelled
+func (s *Server) runOutgoingWatcher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.watchOutgoingTransactions(ctx)
+			s.fillNonceGaps(ctx)
+		}
+	}
+}
+
+// watchOutgoingTransactions updates the status of queued and pending transactions
+func (s *Server) watchOutgoingTransactions(ctx context.Context) {
+	for _, status := range []string{models.OutgoingStatusQueued, models.OutgoingStatusBroadcast, models.OutgoingStatusCancelling} {
+		jobs, err := s.store.outgoingRepo.GetByStatus(ctx, status)
+		if err != nil {
+			log.Printf("Warning: failed to load %s outgoing transactions: %v", status, err)
+			continue
+		}
+
+		for _, job := range jobs {
+			if ctx.Err() != nil {
+				return
+			}
+			if err := s.watchOutgoingTransaction(ctx, job); err != nil {
+				log.Printf("Warning: failed to check outgoing transaction %d: %v", job.ID, err)
+			}
+		}
+	}
+}
+
+// watchOutgoingTransaction checks a single job against the chain and stores its new status
+func (s *Server) watchOutgoingTransaction(ctx context.Context, job *models.OutgoingTransaction) error {
+	// The job is reloaded under its lock, since an admin may have replaced it since it was listed
+	unlock := s.jobLocks.lock(job.ID)
+	defer unlock()
+
+	current, err := s.store.outgoingRepo.GetByID(ctx, job.ID)
+	if err != nil || current == nil {
+		return err
+	}
+	*job = *current
+
+	if job.Status == models.OutgoingStatusQueued {
+		return s.recoverQueued(ctx, job)
+	}
+	if job.Status != models.OutgoingStatusBroadcast && job.Status != models.OutgoingStatusCancelling {
+		// Settled since it was listed
+		return nil
+	}
+
+	hash, receipt, err := s.findMined(ctx, job)
+	if err != nil {
+		return err
+	}
+	if receipt != nil {
+		return s.markMined(ctx, job, hash, receipt)
+	}
+
+	// Not mined yet; once the account's mined nonce moved past it, another transaction took its place
+	minedNonce, err := s.eth.NonceAt(ctx, common.HexToAddress(job.From), nil)
+	if err != nil {
+		return err
+	}
+	if minedNonce > job.Nonce {
+		// One of the job's transactions may have been mined since the receipts were looked up,
+		// or the lookups went to a lagging endpoint, so they are checked again
+		hash, receipt, err := s.findMined(ctx, job)
+		if err != nil {
+			return err
+		}
+		if receipt != nil {
+			return s.markMined(ctx, job, hash, receipt)
+		}
+
+		job.Status = models.OutgoingStatusReplaced
+		return s.store.outgoingRepo.Update(ctx, job)
+	}
+
+	if job.BroadcastAt == nil || time.Since(*job.BroadcastAt) <= s.stuckTimeout {
+		return nil
+	}
+	if len(job.PreviousHashes) >= s.maxFeeBumps {
+		log.Printf("Warning: outgoing transaction %d is pending since %s and was already replaced %d times, leaving it to admins",
+			job.ID, job.BroadcastAt.Format(time.RFC3339), len(job.PreviousHashes))
+		return nil
+	}
+	// Bumping fees doesn't help a transaction waiting behind a missing nonce, which the node
+	// reports as a pending nonce below it; the gap is filled by fillNonceGaps instead
+	pendingNonce, err := s.eth.PendingNonceAt(ctx, common.HexToAddress(job.From))
+	if err != nil {
+		return err
+	}
+	if job.Nonce > pendingNonce {
+		log.Printf("Warning: outgoing transaction %d is waiting for nonce %d to be used, not speeding it up", job.ID, pendingNonce)
+		return nil
+	}
+
+	log.Printf("Outgoing transaction %d is pending since %s, rebroadcasting it with bumped fees", job.ID, job.BroadcastAt.Format(time.RFC3339))
+	return s.replace(ctx, job, false)
+}
+
+// findMined returns the hash and receipt of the job's transaction that was mined, if any.
+// Any of the replaced transactions may have been mined instead of the latest one.
+func (s *Server) findMined(ctx context.Context, job *models.OutgoingTransaction) (string, *types.Receipt, error) {
+	hashes := append([]string{job.TransactionHash}, job.PreviousHashes...)
+	for _, hash := range hashes {
+		receipt, err := s.eth.TransactionReceipt(ctx, common.HexToHash(hash))
+		if errors.Is(err, ethereum.NotFound) {
+			continue
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		return hash, receipt, nil
+	}
+	return "", nil, nil
+}
+
+// markMined stores the outcome of the job's transaction with the given hash
+func (s *Server) markMined(ctx context.Context, job *models.OutgoingTransaction, hash string, receipt *types.Receipt) error {
+	status := models.OutgoingStatusMined
+	switch {
+	case receipt.Status == types.ReceiptStatusFailed:
+		status = models.OutgoingStatusFailed
+		job.Error = "transaction reverted"
+	case job.Status == models.OutgoingStatusCancelling:
+		// The original or one of its speed-ups may have been mined before the cancel
+		tx, _, err := s.eth.TransactionByHash(ctx, common.HexToHash(hash))
+		if err != nil {
+			return err
+		}
+		if tx.To() != nil && *tx.To() == common.HexToAddress(job.From) && len(tx.Data()) == 0 {
+			status = models.OutgoingStatusCancelled
+		}
+	}
+
+	// The mined transaction becomes the job's transaction
+	if hash != job.TransactionHash {
+		job.PreviousHashes = slices.DeleteFunc(job.PreviousHashes, func(h string) bool { return h == hash })
+		job.PreviousHashes = append(job.PreviousHashes, job.TransactionHash)
+		job.TransactionHash = hash
+	}
+
+	now := time.Now()
+	job.Status = status
+	job.BlockNumber = receipt.BlockNumber.Uint64()
+	job.MinedAt = &now
+	return s.store.outgoingRepo.Update(ctx, job)
+}
+
+// recoverQueued resolves jobs left queued by a crash or restart during broadcast
+func (s *Server) recoverQueued(ctx context.Context, job *models.OutgoingTransaction) error {
+	if time.Since(job.CreatedAt) < queuedTimeout {
+		return nil
+	}
+
+	if job.TransactionHash != "" {
+		_, _, err := s.eth.TransactionByHash(ctx, common.HexToHash(job.TransactionHash))
+		if err == nil {
+			job.Status = models.OutgoingStatusBroadcast
+			return s.store.outgoingRepo.Update(ctx, job)
+		}
+		if !errors.Is(err, ethereum.NotFound) {
+			return err
+		}
+	}
+
+	job.Status = models.OutgoingStatusFailed
+	job.Error = "transaction was never broadcast"
+	return s.store.outgoingRepo.Update(ctx, job)
+}
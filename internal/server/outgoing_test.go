@@ -0,0 +1,620 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"ethereum-fetcher-go/internal/chain"
+	"ethereum-fetcher-go/internal/models"
+	"ethereum-fetcher-go/internal/repository"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gin-gonic/gin"
+)
+
+// fakeChain is a chain.Service serving transactions and receipts from a fakeBackend
+// and recording sent transactions
+type fakeChain struct {
+	chain.Service
+	backend    *fakeBackend
+	minedNonce uint64
+	gasErr     error
+	sendErr    error
+	// feeDelay slows down fee estimation, widening the window of concurrent replacements
+	feeDelay time.Duration
+	sent     []*types.Transaction
+}
+
+func (f *fakeChain) ChainID(context.Context) (*big.Int, error) {
+	return testChainID, nil
+}
+
+func (f *fakeChain) FeeHistory(context.Context, uint64, *big.Int, []float64) (*ethereum.FeeHistory, error) {
+	time.Sleep(f.feeDelay)
+	return &ethereum.FeeHistory{
+		BaseFee: []*big.Int{big.NewInt(100), big.NewInt(100)},
+		Reward:  [][]*big.Int{{big.NewInt(2)}},
+	}, nil
+}
+
+func (f *fakeChain) EstimateGas(context.Context, ethereum.CallMsg) (uint64, error) {
+	return 50000, f.gasErr
+}
+
+func (f *fakeChain) SendTransaction(_ context.Context, tx *types.Transaction) error {
+	f.sent = append(f.sent, tx)
+	return f.sendErr
+}
+
+func (f *fakeChain) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	return f.backend.TransactionByHash(ctx, hash)
+}
+
+func (f *fakeChain) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	return f.backend.TransactionReceipt(ctx, hash)
+}
+
+func (f *fakeChain) NonceAt(_ context.Context, _ common.Address, _ *big.Int) (uint64, error) {
+	return f.minedNonce, nil
+}
+
+func (f *fakeChain) PendingNonceAt(_ context.Context, _ common.Address) (uint64, error) {
+	return f.minedNonce, nil
+}
+
+// fakeAccountNonceRepo is a repository.AccountNonceRepository keeping nonces in memory
+type fakeAccountNonceRepo struct {
+	repository.AccountNonceRepository
+	nonces map[string]*models.AccountNonce
+}
+
+func (r *fakeAccountNonceRepo) GetByAddress(_ context.Context, address string) (*models.AccountNonce, error) {
+	return r.nonces[address], nil
+}
+
+func (r *fakeAccountNonceRepo) Upsert(_ context.Context, nonce *models.AccountNonce) error {
+	r.nonces[nonce.Address] = nonce
+	return nil
+}
+
+// fakeOutgoingRepo is a repository.OutgoingTransactionRepository keeping jobs in memory.
+// Like a database, it hands out and stores copies of the jobs.
+type fakeOutgoingRepo struct {
+	repository.OutgoingTransactionRepository
+	mu   sync.Mutex
+	jobs []*models.OutgoingTransaction
+}
+
+// copyJob returns a copy of the job that shares no memory with it
+func copyJob(job *models.OutgoingTransaction) *models.OutgoingTransaction {
+	c := *job
+	c.PreviousHashes = slices.Clone(job.PreviousHashes)
+	return &c
+}
+
+func (r *fakeOutgoingRepo) Create(_ context.Context, job *models.OutgoingTransaction) (*models.OutgoingTransaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job.ID = len(r.jobs) + 1
+	r.jobs = append(r.jobs, copyJob(job))
+	return job, nil
+}
+
+func (r *fakeOutgoingRepo) GetByID(_ context.Context, id int) (*models.OutgoingTransaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, job := range r.jobs {
+		if job.ID == id {
+			return copyJob(job), nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeOutgoingRepo) GetByStatus(_ context.Context, status string) ([]*models.OutgoingTransaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var jobs []*models.OutgoingTransaction
+	for _, job := range r.jobs {
+		if job.Status == status {
+			jobs = append(jobs, copyJob(job))
+		}
+	}
+	return jobs, nil
+}
+
+func (r *fakeOutgoingRepo) Update(_ context.Context, job *models.OutgoingTransaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, stored := range r.jobs {
+		if stored.ID == job.ID && stored != job {
+			*stored = *copyJob(job)
+		}
+	}
+	return nil
+}
+
+func TestWatchOutgoingTransactions(t *testing.T) {
+	minedHash := common.HexToHash("0x01")
+	revertedHash := common.HexToHash("0x02")
+	pendingHash := common.HexToHash("0x03")
+	droppedHash := common.HexToHash("0x04")
+
+	tests := []struct {
+		name       string
+		job        models.OutgoingTransaction
+		minedNonce uint64
+		wantStatus string
+	}{
+		{
+			name:       "mined",
+			job:        models.OutgoingTransaction{Status: models.OutgoingStatusBroadcast, TransactionHash: minedHash.Hex(), Nonce: 4},
+			minedNonce: 5,
+			wantStatus: models.OutgoingStatusMined,
+		},
+		{
+			name:       "reverted",
+			job:        models.OutgoingTransaction{Status: models.OutgoingStatusBroadcast, TransactionHash: revertedHash.Hex(), Nonce: 4},
+			minedNonce: 5,
+			wantStatus: models.OutgoingStatusFailed,
+		},
+		{
+			name:       "pending",
+			job:        models.OutgoingTransaction{Status: models.OutgoingStatusBroadcast, TransactionHash: pendingHash.Hex(), Nonce: 5},
+			minedNonce: 5,
+			wantStatus: models.OutgoingStatusBroadcast,
+		},
+		{
+			name:       "replaced",
+			job:        models.OutgoingTransaction{Status: models.OutgoingStatusBroadcast, TransactionHash: droppedHash.Hex(), Nonce: 4},
+			minedNonce: 5,
+			wantStatus: models.OutgoingStatusReplaced,
+		},
+		{
+			name:       "recently queued",
+			job:        models.OutgoingTransaction{Status: models.OutgoingStatusQueued, CreatedAt: time.Now()},
+			wantStatus: models.OutgoingStatusQueued,
+		},
+		{
+			name:       "queued and sent before a restart",
+			job:        models.OutgoingTransaction{Status: models.OutgoingStatusQueued, TransactionHash: pendingHash.Hex(), CreatedAt: time.Now().Add(-time.Hour)},
+			wantStatus: models.OutgoingStatusBroadcast,
+		},
+		{
+			name:       "queued and never sent",
+			job:        models.OutgoingTransaction{Status: models.OutgoingStatusQueued, TransactionHash: droppedHash.Hex(), CreatedAt: time.Now().Add(-time.Hour)},
+			wantStatus: models.OutgoingStatusFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &fakeBackend{
+				txs: map[common.Hash]*types.Transaction{
+					minedHash:    types.NewTx(&types.LegacyTx{}),
+					revertedHash: types.NewTx(&types.LegacyTx{}),
+					pendingHash:  types.NewTx(&types.LegacyTx{}),
+				},
+				receipts: map[common.Hash]*types.Receipt{
+					minedHash:    {Status: types.ReceiptStatusSuccessful, BlockNumber: big.NewInt(100)},
+					revertedHash: {Status: types.ReceiptStatusFailed, BlockNumber: big.NewInt(100)},
+				},
+			}
+			job := tt.job
+			s := &Server{
+				eth:   &fakeChain{backend: backend, minedNonce: tt.minedNonce},
+				store: &Store{outgoingRepo: &fakeOutgoingRepo{jobs: []*models.OutgoingTransaction{&job}}},
+			}
+
+			s.watchOutgoingTransactions(context.Background())
+
+			if job.Status != tt.wantStatus {
+				t.Errorf("expected status %s, got %s", tt.wantStatus, job.Status)
+			}
+			if tt.wantStatus == models.OutgoingStatusMined && (job.BlockNumber != 100 || job.MinedAt == nil) {
+				t.Errorf("expected mined at block 100, got block %d at %v", job.BlockNumber, job.MinedAt)
+			}
+		})
+	}
+}
+
+func TestWatchOutgoingTransactionsMinedDuringCheck(t *testing.T) {
+	previousHash := common.HexToHash("0x01")
+	latestHash := common.HexToHash("0x02")
+	backend := &fakeBackend{
+		txs:      map[common.Hash]*types.Transaction{},
+		receipts: map[common.Hash]*types.Receipt{previousHash: {Status: types.ReceiptStatusSuccessful, BlockNumber: big.NewInt(100)}},
+	}
+	job := &models.OutgoingTransaction{
+		Status:          models.OutgoingStatusBroadcast,
+		TransactionHash: latestHash.Hex(),
+		PreviousHashes:  []string{previousHash.Hex()},
+		Nonce:           4,
+	}
+	// Both lookups of the first pass miss, the replaced transaction is found when they are checked again
+	s := &Server{
+		eth:   &lateReceiptChain{fakeChain: &fakeChain{backend: backend, minedNonce: 5}, misses: 2},
+		store: &Store{outgoingRepo: &fakeOutgoingRepo{jobs: []*models.OutgoingTransaction{job}}},
+	}
+
+	s.watchOutgoingTransactions(context.Background())
+
+	if job.Status != models.OutgoingStatusMined || job.TransactionHash != previousHash.Hex() || job.BlockNumber != 100 {
+		t.Errorf("expected %s to be mined at block 100, got %s with %s at block %d", previousHash.Hex(), job.Status, job.TransactionHash, job.BlockNumber)
+	}
+}
+
+func TestWatchOutgoingTransactionsSpeedsUpStuck(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := chain.NewKeySigner(key)
+	from := signer.Address()
+
+	broadcastAt := time.Now().Add(-time.Hour)
+	job := &models.OutgoingTransaction{
+		Status:               models.OutgoingStatusBroadcast,
+		TransactionHash:      common.HexToHash("0x01").Hex(),
+		From:                 from.Hex(),
+		To:                   common.HexToAddress("0x02").Hex(),
+		Nonce:                3,
+		Input:                "0x1234",
+		GasLimit:             60000,
+		MaxFeePerGas:         models.NewBigInt(big.NewInt(1000)),
+		MaxPriorityFeePerGas: models.NewBigInt(big.NewInt(10)),
+		BroadcastAt:          &broadcastAt,
+	}
+	eth := &fakeChain{backend: &fakeBackend{}, minedNonce: 3}
+	s := &Server{
+		eth:          eth,
+		signer:       signer,
+		store:        &Store{outgoingRepo: &fakeOutgoingRepo{jobs: []*models.OutgoingTransaction{job}}},
+		stuckTimeout: time.Minute,
+		maxFeeBumps:  defaultMaxFeeBumps,
+	}
+
+	s.watchOutgoingTransactions(context.Background())
+
+	if len(eth.sent) != 1 {
+		t.Fatalf("expected 1 replacement to be sent, got %d", len(eth.sent))
+	}
+	replacement := eth.sent[0]
+	if replacement.Nonce() != 3 {
+		t.Errorf("expected the replacement to reuse nonce 3, got %d", replacement.Nonce())
+	}
+	if replacement.GasFeeCap().Int64() != 1150 || replacement.GasTipCap().Int64() != 12 {
+		t.Errorf("expected fees to be bumped to 1150/12, got %s/%s", replacement.GasFeeCap(), replacement.GasTipCap())
+	}
+	if job.TransactionHash != replacement.Hash().Hex() || len(job.PreviousHashes) != 1 || job.PreviousHashes[0] != common.HexToHash("0x01").Hex() {
+		t.Errorf("expected the job to track the replacement and the previous hash, got %s and %v", job.TransactionHash, job.PreviousHashes)
+	}
+	if !job.BroadcastAt.After(broadcastAt) {
+		t.Errorf("expected the broadcast time to be updated")
+	}
+}
+
+func TestWatchOutgoingTransactionsSpeedUpLimits(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := chain.NewKeySigner(key)
+
+	tests := []struct {
+		name           string
+		previousHashes []string
+		maxFeeBumps    int
+		pendingNonce   uint64
+	}{
+		{"bumped too often", []string{common.HexToHash("0x02").Hex(), common.HexToHash("0x03").Hex()}, 2, 3},
+		{"speed-ups disabled", nil, 0, 3},
+		{"waiting behind a nonce gap", nil, defaultMaxFeeBumps, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			broadcastAt := time.Now().Add(-time.Hour)
+			job := &models.OutgoingTransaction{
+				Status:               models.OutgoingStatusBroadcast,
+				TransactionHash:      common.HexToHash("0x01").Hex(),
+				PreviousHashes:       tt.previousHashes,
+				From:                 signer.Address().Hex(),
+				To:                   common.HexToAddress("0x02").Hex(),
+				Nonce:                3,
+				Input:                "0x1234",
+				GasLimit:             60000,
+				MaxFeePerGas:         models.NewBigInt(big.NewInt(1000)),
+				MaxPriorityFeePerGas: models.NewBigInt(big.NewInt(10)),
+				BroadcastAt:          &broadcastAt,
+			}
+			eth := &fakeChain{backend: &fakeBackend{}, minedNonce: tt.pendingNonce}
+			s := &Server{
+				eth:          eth,
+				signer:       signer,
+				store:        &Store{outgoingRepo: &fakeOutgoingRepo{jobs: []*models.OutgoingTransaction{job}}},
+				stuckTimeout: time.Minute,
+				maxFeeBumps:  tt.maxFeeBumps,
+			}
+
+			s.watchOutgoingTransactions(context.Background())
+
+			if len(eth.sent) != 0 {
+				t.Errorf("expected the job not to be sped up, got %d replacements", len(eth.sent))
+			}
+			if job.Status != models.OutgoingStatusBroadcast || job.TransactionHash != common.HexToHash("0x01").Hex() {
+				t.Errorf("expected the job to be left pending, got %s with %s", job.Status, job.TransactionHash)
+			}
+		})
+	}
+}
+
+func TestFillNonceGaps(t *testing.T) {
+	ctx := context.Background()
+	key, _ := crypto.GenerateKey()
+	signer := chain.NewKeySigner(key)
+	from := signer.Address()
+
+	eth := &fakeChain{backend: &fakeBackend{}, minedNonce: 7}
+	outgoingRepo := &fakeOutgoingRepo{}
+	s := &Server{
+		eth:    eth,
+		signer: signer,
+		nonces: chain.NewNonceManager(eth, nonceStore{
+			repo:         &fakeAccountNonceRepo{nonces: make(map[string]*models.AccountNonce)},
+			outgoingRepo: outgoingRepo,
+		}),
+		store: &Store{outgoingRepo: outgoingRepo},
+	}
+
+	// Nonce 7 is released after nonce 8 was sent, leaving a gap
+	for range 2 {
+		if _, err := s.nonces.Next(ctx, from); err != nil {
+			t.Fatalf("Next() returned error: %v", err)
+		}
+	}
+	s.nonces.Done(from, 8)
+	if err := s.nonces.Release(ctx, from, 7); err != nil {
+		t.Fatalf("Release() returned error: %v", err)
+	}
+
+	s.fillNonceGaps(ctx)
+
+	if len(eth.sent) != 1 {
+		t.Fatalf("expected 1 transaction to be sent, got %d", len(eth.sent))
+	}
+	if tx := eth.sent[0]; tx.Nonce() != 7 || *tx.To() != from || tx.Value().Sign() != 0 || len(tx.Data()) != 0 {
+		t.Errorf("expected a 0-value transfer to the sender with nonce 7, got nonce %d to %s", tx.Nonce(), tx.To().Hex())
+	}
+	if len(outgoingRepo.jobs) != 1 || outgoingRepo.jobs[0].Kind != models.OutgoingKindFillNonce || outgoingRepo.jobs[0].Status != models.OutgoingStatusBroadcast {
+		t.Errorf("expected a broadcast fillNonce job, got %+v", outgoingRepo.jobs)
+	}
+	if nonce, _ := s.nonces.Next(ctx, from); nonce != 9 {
+		t.Errorf("expected nonce 9 once the gap was filled, got %d", nonce)
+	}
+}
+
+func TestReplaceCancel(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := chain.NewKeySigner(key)
+	from := signer.Address()
+
+	job := &models.OutgoingTransaction{
+		Status:               models.OutgoingStatusBroadcast,
+		TransactionHash:      common.HexToHash("0x01").Hex(),
+		From:                 from.Hex(),
+		To:                   common.HexToAddress("0x02").Hex(),
+		Input:                "0x1234",
+		GasLimit:             60000,
+		MaxFeePerGas:         models.NewBigInt(big.NewInt(1000)),
+		MaxPriorityFeePerGas: models.NewBigInt(big.NewInt(10)),
+	}
+	backend := &fakeBackend{txs: map[common.Hash]*types.Transaction{}, receipts: map[common.Hash]*types.Receipt{}}
+	eth := &fakeChain{backend: backend}
+	s := &Server{eth: eth, signer: signer, store: &Store{outgoingRepo: &fakeOutgoingRepo{jobs: []*models.OutgoingTransaction{job}}}}
+
+	if err := s.replace(context.Background(), job, true); err != nil {
+		t.Fatalf("replace() returned error: %v", err)
+	}
+	cancel := eth.sent[0]
+	if cancel.To() == nil || *cancel.To() != from || cancel.Value().Sign() != 0 || len(cancel.Data()) != 0 {
+		t.Fatalf("expected a 0-value transfer to the sender, got %+v", cancel)
+	}
+	if job.Status != models.OutgoingStatusCancelling {
+		t.Errorf("expected status %s, got %s", models.OutgoingStatusCancelling, job.Status)
+	}
+
+	// The cancel is mined
+	backend.txs[cancel.Hash()] = cancel
+	backend.receipts[cancel.Hash()] = &types.Receipt{Status: types.ReceiptStatusSuccessful, BlockNumber: big.NewInt(100)}
+	s.watchOutgoingTransactions(context.Background())
+
+	if job.Status != models.OutgoingStatusCancelled {
+		t.Errorf("expected status %s, got %s", models.OutgoingStatusCancelled, job.Status)
+	}
+
+	if err := s.replace(context.Background(), job, false); err != errNotPending {
+		t.Errorf("expected errNotPending for a cancelled job, got %v", err)
+	}
+}
+
+func TestBroadcastSendErrors(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := chain.NewKeySigner(key)
+
+	tests := []struct {
+		name       string
+		sendErr    error
+		wantErr    bool
+		wantStatus string
+		// wantNext is the nonce allocated after the broadcast
+		wantNext uint64
+	}{
+		{"sent", nil, false, models.OutgoingStatusBroadcast, 8},
+		{"already known", errors.New("already known"), false, models.OutgoingStatusBroadcast, 8},
+		{"timed out", fmt.Errorf("post http://node: %w", context.DeadlineExceeded), false, models.OutgoingStatusBroadcast, 8},
+		{"underpriced", errors.New("transaction underpriced"), true, models.OutgoingStatusQueued, 7},
+		{"insufficient funds", errors.New("insufficient funds for gas * price + value"), true, models.OutgoingStatusQueued, 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eth := &fakeChain{backend: &fakeBackend{}, minedNonce: 7, sendErr: tt.sendErr}
+			outgoingRepo := &fakeOutgoingRepo{}
+			s := &Server{
+				eth:    eth,
+				signer: signer,
+				nonces: chain.NewNonceManager(eth, nonceStore{
+					repo:         &fakeAccountNonceRepo{nonces: make(map[string]*models.AccountNonce)},
+					outgoingRepo: outgoingRepo,
+				}),
+				store: &Store{outgoingRepo: outgoingRepo},
+			}
+			job := &models.OutgoingTransaction{
+				Status:               models.OutgoingStatusQueued,
+				From:                 signer.Address().Hex(),
+				To:                   common.HexToAddress("0x02").Hex(),
+				Input:                "0x1234",
+				GasLimit:             60000,
+				MaxFeePerGas:         models.NewBigInt(big.NewInt(1000)),
+				MaxPriorityFeePerGas: models.NewBigInt(big.NewInt(10)),
+			}
+
+			err := s.broadcast(context.Background(), job)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %t, got %v", tt.wantErr, err)
+			}
+			if job.Status != tt.wantStatus {
+				t.Errorf("expected status %s, got %s", tt.wantStatus, job.Status)
+			}
+			if job.TransactionHash != eth.sent[0].Hash().Hex() {
+				t.Errorf("expected the job to track the sent transaction")
+			}
+
+			// Only nonces of rejected transactions are allocated again
+			if next, _ := s.nonces.Next(context.Background(), signer.Address()); next != tt.wantNext {
+				t.Errorf("expected next nonce %d, got %d", tt.wantNext, next)
+			}
+		})
+	}
+}
+
+func TestCancelRacesWatcherSpeedUp(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := chain.NewKeySigner(key)
+	from := signer.Address()
+
+	// Whichever replaces the stuck job first, the cancel ends up as its transaction
+	for range 20 {
+		broadcastAt := time.Now().Add(-time.Hour)
+		repo := &fakeOutgoingRepo{jobs: []*models.OutgoingTransaction{{
+			ID:                   1,
+			Status:               models.OutgoingStatusBroadcast,
+			TransactionHash:      common.HexToHash("0x01").Hex(),
+			From:                 from.Hex(),
+			To:                   common.HexToAddress("0x02").Hex(),
+			Nonce:                3,
+			Input:                "0x1234",
+			GasLimit:             60000,
+			MaxFeePerGas:         models.NewBigInt(big.NewInt(1000)),
+			MaxPriorityFeePerGas: models.NewBigInt(big.NewInt(10)),
+			BroadcastAt:          &broadcastAt,
+		}}}
+		eth := &fakeChain{backend: &fakeBackend{}, minedNonce: 3, feeDelay: 10 * time.Millisecond}
+		s := &Server{eth: eth, signer: signer, store: &Store{outgoingRepo: repo}, stuckTimeout: time.Minute, maxFeeBumps: defaultMaxFeeBumps}
+		r := gin.New()
+		r.POST("/lime/admin/outgoing/:id/cancel", s.cancelOutgoingHandler)
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			s.watchOutgoingTransactions(context.Background())
+		}()
+		go func() {
+			defer wg.Done()
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/lime/admin/outgoing/1/cancel", nil))
+			if rr.Code != http.StatusOK {
+				t.Errorf("expected status 200, got %d: %s", rr.Code, rr.Body.String())
+			}
+		}()
+		wg.Wait()
+
+		job, _ := repo.GetByID(context.Background(), 1)
+		last := eth.sent[len(eth.sent)-1]
+		if last.To() == nil || *last.To() != from {
+			t.Fatalf("expected the cancel to be sent last, got a transaction to %v", last.To())
+		}
+		if job.Status != models.OutgoingStatusCancelling || job.TransactionHash != last.Hash().Hex() {
+			t.Fatalf("expected the job to track the cancel, got status %s and hash %s", job.Status, job.TransactionHash)
+		}
+		// No replacement is lost, since any of them may be mined
+		for _, tx := range eth.sent[:len(eth.sent)-1] {
+			if !slices.Contains(job.PreviousHashes, tx.Hash().Hex()) {
+				t.Errorf("expected %s among the previous hashes %v", tx.Hash().Hex(), job.PreviousHashes)
+			}
+		}
+	}
+}
+
+func TestReplaceSendErrors(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := chain.NewKeySigner(key)
+	original := common.HexToHash("0x01").Hex()
+
+	tests := []struct {
+		name    string
+		sendErr error
+		wantErr bool
+	}{
+		{"already known", errors.New("already known"), false},
+		{"timed out", fmt.Errorf("post http://node: %w", context.DeadlineExceeded), false},
+		{"underpriced", errors.New("replacement transaction underpriced"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeOutgoingRepo{jobs: []*models.OutgoingTransaction{{
+				ID:                   1,
+				Status:               models.OutgoingStatusBroadcast,
+				TransactionHash:      original,
+				From:                 signer.Address().Hex(),
+				To:                   common.HexToAddress("0x02").Hex(),
+				Input:                "0x1234",
+				GasLimit:             60000,
+				MaxFeePerGas:         models.NewBigInt(big.NewInt(1000)),
+				MaxPriorityFeePerGas: models.NewBigInt(big.NewInt(10)),
+			}}}
+			eth := &fakeChain{backend: &fakeBackend{}, sendErr: tt.sendErr}
+			s := &Server{eth: eth, signer: signer, store: &Store{outgoingRepo: repo}}
+
+			job, _ := repo.GetByID(context.Background(), 1)
+			err := s.replace(context.Background(), job, false)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %t, got %v", tt.wantErr, err)
+			}
+
+			stored, _ := repo.GetByID(context.Background(), 1)
+			attempted := eth.sent[0].Hash().Hex()
+			if tt.wantErr {
+				// The rejected replacement is still checked by the watcher, but the original stays current
+				if stored.TransactionHash != original || stored.MaxFeePerGas.Int64() != 1000 {
+					t.Errorf("expected the original transaction to be restored, got %s with fee cap %s", stored.TransactionHash, &stored.MaxFeePerGas.Int)
+				}
+				if !slices.Equal(stored.PreviousHashes, []string{attempted}) {
+					t.Errorf("expected the attempted hash among the previous hashes, got %v", stored.PreviousHashes)
+				}
+				return
+			}
+			if stored.TransactionHash != attempted || !slices.Equal(stored.PreviousHashes, []string{original}) {
+				t.Errorf("expected the replacement to be kept, got %s with previous hashes %v", stored.TransactionHash, stored.PreviousHashes)
+			}
+		})
+	}
+}
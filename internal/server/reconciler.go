@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"ethereum-fetcher-go/internal/models"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	// defaultReconcileInterval is used when RECONCILE_INTERVAL is not set
+	defaultReconcileInterval = 15 * time.Second
+
+	// droppedAfter is how long a transaction may be unknown to the node before it is considered dropped
+	droppedAfter = 10 * time.Minute
+)
+
+// runReconciler periodically completes stored pending transactions until ctx is cancelled
+func (s *Server) runReconciler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.reconcilePendingTransactions(ctx)
+		}
+	}
+}
+
+// reconcilePendingTransactions polls the receipts of pending transactions and updates
+// them once mined, or marks them as replaced or dropped if they will never be mined
+func (s *Server) reconcilePendingTransactions(ctx context.Context) {
+	pending, err := s.store.transactionRepo.GetByStatus(ctx, models.TransactionStatusPending)
+	if err != nil {
+		log.Printf("Warning: failed to load pending transactions: %v", err)
+		return
+	}
+
+	for _, transaction := range pending {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err := s.reconcileTransaction(ctx, transaction); err != nil {
+			log.Printf("Warning: failed to reconcile transaction %s: %v", transaction.TransactionHash, err)
+		}
+	}
+}
+
+// reconcileTransaction refreshes the status of a single pending transaction
+func (s *Server) reconcileTransaction(ctx context.Context, transaction *models.Transaction) error {
+	txHash := common.HexToHash(transaction.TransactionHash)
+
+	receipt, err := s.eth.TransactionReceipt(ctx, txHash)
+	if err == nil {
+		applyReceipt(transaction, receipt)
+		return s.store.transactionRepo.Update(ctx, transaction)
+	}
+	if !errors.Is(err, ethereum.NotFound) {
+		return err
+	}
+
+	// Without a receipt, check whether the node still knows the transaction
+	_, _, err = s.eth.TransactionByHash(ctx, txHash)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, ethereum.NotFound) {
+		return err
+	}
+
+	// Once the account moved past its nonce, this transaction or another one with the same nonce was mined
+	nonce, err := s.eth.NonceAt(ctx, common.HexToAddress(transaction.From), nil)
+	if err != nil {
+		return err
+	}
+	if nonce > transaction.Nonce {
+		// The transaction itself may have been mined since its receipt was looked up,
+		// or the lookup went to a lagging endpoint, so its receipt is checked again
+		receipt, err := s.eth.TransactionReceipt(ctx, txHash)
+		if err == nil {
+			applyReceipt(transaction, receipt)
+			return s.store.transactionRepo.Update(ctx, transaction)
+		}
+		if !errors.Is(err, ethereum.NotFound) {
+			return err
+		}
+
+		transaction.Status = models.TransactionStatusReplaced
+		return s.store.transactionRepo.Update(ctx, transaction)
+	}
+
+	// Give the transaction time to propagate before giving up on it
+	if time.Since(transaction.CreatedAt) > droppedAfter {
+		transaction.Status = models.TransactionStatusDropped
+		return s.store.transactionRepo.Update(ctx, transaction)
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"ethereum-fetcher-go/internal/models"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestReconcileTransaction(t *testing.T) {
+	minedHash := common.HexToHash("0x01")
+	pendingHash := common.HexToHash("0x02")
+	unknownHash := common.HexToHash("0x03")
+	failingHash := common.HexToHash("0x04")
+
+	tests := []struct {
+		name       string
+		hash       common.Hash
+		createdAt  time.Time
+		minedNonce uint64
+		wantStatus string
+		wantErr    bool
+	}{
+		{name: "mined", hash: minedHash, createdAt: time.Now(), wantStatus: models.TransactionStatusMined},
+		{name: "still pending", hash: pendingHash, createdAt: time.Now().Add(-time.Hour), wantStatus: models.TransactionStatusPending},
+		{name: "replaced", hash: unknownHash, createdAt: time.Now(), minedNonce: 6, wantStatus: models.TransactionStatusReplaced},
+		{name: "unknown but recent", hash: unknownHash, createdAt: time.Now(), minedNonce: 5, wantStatus: models.TransactionStatusPending},
+		{name: "dropped", hash: unknownHash, createdAt: time.Now().Add(-droppedAfter - time.Minute), minedNonce: 5, wantStatus: models.TransactionStatusDropped},
+		{name: "rpc error", hash: failingHash, createdAt: time.Now().Add(-time.Hour), wantStatus: models.TransactionStatusPending, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &fakeBackend{
+				txs: map[common.Hash]*types.Transaction{
+					minedHash:   types.NewTx(&types.LegacyTx{}),
+					pendingHash: types.NewTx(&types.LegacyTx{}),
+				},
+				receipts: map[common.Hash]*types.Receipt{
+					minedHash: {Status: types.ReceiptStatusSuccessful, BlockNumber: big.NewInt(100), GasUsed: 21000, EffectiveGasPrice: big.NewInt(2)},
+				},
+				errs: map[common.Hash]error{failingHash: errors.New("connection refused")},
+			}
+			repo := &fakeTransactionRepo{}
+			s := &Server{
+				eth:   &fakeChain{backend: backend, minedNonce: tt.minedNonce},
+				store: &Store{transactionRepo: repo},
+			}
+			transaction := &models.Transaction{
+				TransactionHash: tt.hash.Hex(),
+				Status:          models.TransactionStatusPending,
+				Nonce:           5,
+				CreatedAt:       tt.createdAt,
+			}
+
+			err := s.reconcileTransaction(context.Background(), transaction)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("reconcileTransaction() returned error %v, want error: %v", err, tt.wantErr)
+			}
+			if transaction.Status != tt.wantStatus {
+				t.Errorf("expected status %s, got %s", tt.wantStatus, transaction.Status)
+			}
+
+			wantUpdates := 1
+			if tt.wantStatus == models.TransactionStatusPending {
+				wantUpdates = 0
+			}
+			if len(repo.updated) != wantUpdates {
+				t.Errorf("expected %d updates, got %d", wantUpdates, len(repo.updated))
+			}
+			if tt.wantStatus == models.TransactionStatusMined && (transaction.BlockNumber != 100 || transaction.Fee.String() != "42000") {
+				t.Errorf("expected the receipt to be applied, got block %d and fee %s", transaction.BlockNumber, transaction.Fee.String())
+			}
+		})
+	}
+}
+
+// lateReceiptChain is a fakeChain whose first receipt lookups miss, like a lagging endpoint
+type lateReceiptChain struct {
+	*fakeChain
+	misses int
+}
+
+func (c *lateReceiptChain) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	if c.misses > 0 {
+		c.misses--
+		return nil, ethereum.NotFound
+	}
+	return c.fakeChain.TransactionReceipt(ctx, hash)
+}
+
+func TestReconcileTransactionMinedDuringCheck(t *testing.T) {
+	hash := common.HexToHash("0x01")
+	backend := &fakeBackend{
+		txs:      map[common.Hash]*types.Transaction{},
+		receipts: map[common.Hash]*types.Receipt{hash: {Status: types.ReceiptStatusSuccessful, BlockNumber: big.NewInt(100)}},
+	}
+	repo := &fakeTransactionRepo{}
+	s := &Server{
+		eth:   &lateReceiptChain{fakeChain: &fakeChain{backend: backend, minedNonce: 6}, misses: 1},
+		store: &Store{transactionRepo: repo},
+	}
+	transaction := &models.Transaction{TransactionHash: hash.Hex(), Status: models.TransactionStatusPending, Nonce: 5, CreatedAt: time.Now()}
+
+	// The nonce moved past the transaction because the transaction itself was mined
+	if err := s.reconcileTransaction(context.Background(), transaction); err != nil {
+		t.Fatalf("reconcileTransaction() returned error: %v", err)
+	}
+	if transaction.Status != models.TransactionStatusMined || transaction.BlockNumber != 100 {
+		t.Errorf("expected the transaction to be mined at block 100, got %s at block %d", transaction.Status, transaction.BlockNumber)
+	}
+}
+
+func TestTransactionResponseStatus(t *testing.T) {
+	pending := &models.Transaction{Status: models.TransactionStatusPending}
+	mined := &models.Transaction{Status: models.TransactionStatusMined, TransactionStatus: 0}
+
+	for _, tt := range []struct {
+		transaction *models.Transaction
+		want        string
+	}{
+		{pending, "null"},
+		{mined, "0"},
+	} {
+		body, _ := json.Marshal(newTransactionResponses([]*models.Transaction{tt.transaction}, "wei")[0])
+
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(body, &fields); err != nil {
+			t.Fatal(err)
+		}
+		if got := string(fields["transactionStatus"]); got != tt.want {
+			t.Errorf("expected transactionStatus %s for a %s transaction, got %s", tt.want, tt.transaction.Status, got)
+		}
+	}
+}
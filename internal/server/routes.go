@@ -3,6 +3,8 @@ package server
 import (
 	"net/http"
 
+	"ethereum-fetcher-go/internal/auth"
+
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
@@ -12,19 +14,42 @@ func (s *Server) RegisterRoutes() http.Handler {
 
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:5173"}, // Add your frontend URL
-		AllowMethods:     []string{"GET", "POST"},
-		AllowHeaders:     []string{"Accept", "Authorization", "Content-Type"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+		AllowHeaders:     []string{"Accept", "Authorization", "Content-Type", "X-API-Key"},
 		AllowCredentials: true, // Enable cookies/auth
 	}))
 
 	r.GET("/health", s.healthHandler)
-	r.GET("/lime/all", s.getAllTransactionsHandler)
-	r.GET("/lime/eth", ValidateTransactionHashes(), s.fetchTransactionsHandler)
-	r.GET("/lime/eth/:rlphex", ValidateRlpHex(), s.fetchTransactionsHandler)
+	r.GET("/.well-known/jwks.json", s.jwksHandler)
+	r.GET("/lime/all", ValidatePage(), ValidateUnits(), s.getAllTransactionsHandler)
+	r.GET("/lime/eth", s.OptionalAuth(auth.ScopeTransactionsRead), ValidateUnits(), ValidateTransactionHashes(), s.fetchTransactionsHandler)
+	r.GET("/lime/eth/:rlphex", s.OptionalAuth(auth.ScopeTransactionsRead), ValidateUnits(), ValidateRlpHex(), s.fetchTransactionsHandler)
+	r.GET("/lime/eth/:rlphex/logs", ValidateTransactionLogsQuery(), s.transactionLogsHandler)
+	r.POST("/lime/abis", s.RequireAuth(auth.ScopeABIsWrite), RequireRole(auth.RoleFetcher), s.registerABIHandler)
+	r.GET("/lime/abis", s.getAllABIsHandler)
+	r.GET("/lime/abis/:address", s.getABIHandler)
 	r.POST("/lime/register", s.registerUserHandler)
 	r.POST("/lime/authenticate", s.authenticateUserHandler)
-	r.GET("/lime/my", s.myUserHandler)
-	r.POST("/lime/savePerson", ValidatePersonData(), s.savePersonHandler)
+	r.POST("/lime/refresh", s.refreshTokenHandler)
+	r.POST("/lime/logout", s.RequireAuth(), s.logoutHandler)
+	r.GET("/lime/siwe/nonce", s.siweNonceHandler)
+	r.POST("/lime/siwe/login", s.siweLoginHandler)
+	r.POST("/lime/wallets", s.RequireAuth(), s.linkWalletHandler)
+	r.GET("/lime/wallets", s.RequireAuth(), s.getWalletsHandler)
+	r.GET("/lime/my", s.RequireAuth(auth.ScopeTransactionsRead), ValidateUnits(), s.myUserHandler)
+	r.GET("/lime/me", s.RequireAuth(), ValidateHistoryQuery(), s.meHandler)
+	r.PATCH("/lime/me", s.RequireAuth(), s.updateProfileHandler)
+	r.DELETE("/lime/me", s.RequireAuth(), s.deleteAccountHandler)
+	r.PUT("/lime/me/password", s.RequireAuth(), s.changePasswordHandler)
+	r.POST("/lime/api-keys", s.RequireAuth(), s.createAPIKeyHandler)
+	r.GET("/lime/api-keys", s.RequireAuth(), s.getAPIKeysHandler)
+	r.DELETE("/lime/api-keys/:id", s.RequireAuth(), s.revokeAPIKeyHandler)
+	r.POST("/lime/savePerson", s.RequireAuth(), RequireRole(auth.RoleSigner), ValidatePersonData(), s.savePersonHandler)
+	r.GET("/lime/savePerson/:id", s.RequireAuth(), s.getSavePersonHandler)
+	r.POST("/lime/admin/outgoing/:id/speedup", s.RequireAuth(), RequireRole(auth.RoleAdmin), s.speedUpOutgoingHandler)
+	r.POST("/lime/admin/outgoing/:id/cancel", s.RequireAuth(), RequireRole(auth.RoleAdmin), s.cancelOutgoingHandler)
+	r.POST("/lime/admin/users/:id/roles", s.RequireAuth(), RequireRole(auth.RoleAdmin), s.grantRoleHandler)
+	r.DELETE("/lime/admin/users/:id/roles/:role", s.RequireAuth(), RequireRole(auth.RoleAdmin), s.revokeRoleHandler)
 
 	return r
 }
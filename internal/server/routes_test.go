@@ -1,19 +1,41 @@
 package server
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"ethereum-fetcher-go/internal/chain"
+	"ethereum-fetcher-go/internal/database"
+
 	"github.com/gin-gonic/gin"
 )
 
+// fakeDB is a database.Service that always reports itself as healthy
+type fakeDB struct {
+	database.Service
+}
+
+func (fakeDB) Health() map[string]string {
+	return map[string]string{"status": "up"}
+}
+
+// fakeEth is a chain.Service that always reports itself as healthy
+type fakeEth struct {
+	chain.Service
+}
+
+func (fakeEth) Health() map[string]any {
+	return map[string]any{"status": "up"}
+}
+
 func TestHealthHandler(t *testing.T) {
-	s := &Server{}
+	s := &Server{db: fakeDB{}, eth: fakeEth{}}
 	r := gin.New()
 	r.GET("/health", s.healthHandler)
 	// Create a test HTTP request
-	req, err := http.NewRequest("GET", "/", nil)
+	req, err := http.NewRequest("GET", "/health", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -26,8 +48,13 @@ func TestHealthHandler(t *testing.T) {
 		t.Errorf("Handler returned wrong status code: got %v want %v", status, http.StatusOK)
 	}
 	// Check the response body
-	expected := "{\"message\":\"Hello World\"}"
-	if rr.Body.String() != expected {
-		t.Errorf("Handler returned unexpected body: got %v want %v", rr.Body.String(), expected)
+	var body map[string]map[string]any
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Handler returned invalid JSON: %v", err)
+	}
+	for _, component := range []string{"database", "ethereum"} {
+		if body[component]["status"] != "up" {
+			t.Errorf("Handler returned unexpected %s status: got %v want up", component, body[component]["status"])
+		}
 	}
 }
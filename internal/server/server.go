@@ -1,45 +1,244 @@
 package server
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"net/http"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	_ "github.com/joho/godotenv/autoload"
 
+	"ethereum-fetcher-go/internal/auth"
+	"ethereum-fetcher-go/internal/chain"
 	"ethereum-fetcher-go/internal/database"
 	"ethereum-fetcher-go/internal/repository"
 )
 
+const (
+	// defaultFetchConcurrency is used when ETH_FETCH_CONCURRENCY is not set
+	defaultFetchConcurrency = 10
+
+	// defaultTokenIssuer and defaultTokenAudience are used when JWT_ISSUER and JWT_AUDIENCE are not set
+	defaultTokenIssuer   = "ethereum-fetcher-go"
+	defaultTokenAudience = "ethereum-fetcher-go"
+
+	// defaultAccessTokenTTL and defaultRefreshTokenTTL are used when JWT_ACCESS_TTL and JWT_REFRESH_TTL are not set
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 7 * 24 * time.Hour
+)
+
 type Store struct {
 	transactionRepo     repository.TransactionRepository
+	transactionLogRepo  repository.TransactionLogRepository
+	contractABIRepo     repository.ContractABIRepository
 	userRepo            repository.UserRepository
 	userTransactionRepo repository.UserTransactionRepository
+	refreshTokenRepo    repository.RefreshTokenRepository
+	revokedTokenRepo    repository.RevokedTokenRepository
+	apiKeyRepo          repository.APIKeyRepository
+	walletRepo          repository.WalletRepository
+	siweNonceRepo       repository.SIWENonceRepository
+	loginAttemptRepo    repository.LoginAttemptRepository
+	outgoingRepo        repository.OutgoingTransactionRepository
+	accountNonceRepo    repository.AccountNonceRepository
 }
 
 type Server struct {
 	port int
 
-	db    database.Service
-	store *Store
+	// fetchConcurrency limits the number of transactions fetched from the network in parallel
+	fetchConcurrency int
+
+	db     database.Service
+	eth    chain.Service
+	store  *Store
+	abis   *abiRegistry
+	tokens *auth.TokenManager
+	logins *auth.LoginLimiter
+	nonces *chain.NonceManager
+	signer chain.Signer
+
+	// jobLocks serializes the watcher and admins changing the same outgoing transaction
+	jobLocks jobLocks
+
+	// feeCaps bounds the fees of transactions sent by the server
+	feeCaps chain.FeeCaps
+	// stuckTimeout is how long a transaction may stay pending before it is rebroadcast with bumped fees
+	stuckTimeout time.Duration
+	// maxFeeBumps is how many times a job may be replaced before the watcher stops speeding it up
+	maxFeeBumps int
+
+	// siweDomain is the domain Sign-In with Ethereum messages must be issued for
+	siweDomain string
+	// siweChainID is the chain Sign-In with Ethereum messages must be issued for
+	siweChainID int64
+
+	// cancel stops the background workers, wg waits for them to exit
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
 }
 
-func NewServer() *http.Server {
+// NewServer creates the HTTP server together with the Server backing it.
+// The returned Server owns long-lived resources and must be closed on shutdown.
+func NewServer() (*http.Server, *Server) {
 	port, _ := strconv.Atoi(os.Getenv("API_PORT"))
 	db := database.New()
 
+	// ETH_NODE_URLS lists several endpoints for failover; ETH_NODE_URL is kept for single-node setups
+	nodeURLs := os.Getenv("ETH_NODE_URLS")
+	if nodeURLs == "" {
+		nodeURLs = os.Getenv("ETH_NODE_URL")
+	}
+	endpoints, err := chain.ParseEndpoints(nodeURLs)
+	if err != nil {
+		log.Fatalf("Invalid Ethereum RPC endpoints: %v", err)
+	}
+
+	eth, err := chain.Dial(endpoints)
+	if err != nil {
+		log.Fatalf("Failed to initialize Ethereum client: %v", err)
+	}
+
+	fetchConcurrency, _ := strconv.Atoi(os.Getenv("ETH_FETCH_CONCURRENCY"))
+	if fetchConcurrency <= 0 {
+		fetchConcurrency = defaultFetchConcurrency
+	}
+
+	reconcileInterval, err := time.ParseDuration(os.Getenv("RECONCILE_INTERVAL"))
+	if err != nil || reconcileInterval <= 0 {
+		reconcileInterval = defaultReconcileInterval
+	}
+
+	accessTTL, err := time.ParseDuration(os.Getenv("JWT_ACCESS_TTL"))
+	if err != nil || accessTTL <= 0 {
+		accessTTL = defaultAccessTokenTTL
+	}
+
+	refreshTTL, err := time.ParseDuration(os.Getenv("JWT_REFRESH_TTL"))
+	if err != nil || refreshTTL <= 0 {
+		refreshTTL = defaultRefreshTokenTTL
+	}
+
+	stuckTimeout, err := time.ParseDuration(os.Getenv("STUCK_TX_TIMEOUT"))
+	if err != nil || stuckTimeout <= 0 {
+		stuckTimeout = defaultStuckTimeout
+	}
+
+	// MAX_FEE_BUMPS of 0 disables automatic speed-ups
+	maxFeeBumps, err := strconv.Atoi(envOrDefault("MAX_FEE_BUMPS", strconv.Itoa(defaultMaxFeeBumps)))
+	if err != nil || maxFeeBumps < 0 {
+		maxFeeBumps = defaultMaxFeeBumps
+	}
+
+	// JWT_KEYS_DIR switches to asymmetric signing; JWT_SECRET then only verifies previously issued
+	// tokens expiring by JWT_SECRET_ACCEPTED_UNTIL, and none if it is not set
+	var keys *auth.KeySet
+	if keysDir := os.Getenv("JWT_KEYS_DIR"); keysDir != "" {
+		var legacySecret string
+		var legacyUntil time.Time
+		if until := os.Getenv("JWT_SECRET_ACCEPTED_UNTIL"); until != "" {
+			legacyUntil, err = time.Parse(time.RFC3339, until)
+			if err != nil {
+				log.Fatalf("JWT_SECRET_ACCEPTED_UNTIL must be an RFC 3339 timestamp: %v", err)
+			}
+			legacySecret = os.Getenv("JWT_SECRET")
+		}
+		keys, err = auth.LoadKeySet(keysDir, os.Getenv("JWT_ACTIVE_KID"), legacySecret, legacyUntil)
+	} else {
+		keys, err = auth.NewHMACKeySet(os.Getenv("JWT_SECRET"))
+	}
+	if err != nil {
+		log.Fatalf("Failed to load JWT signing keys: %v", err)
+	}
+
+	tokens, err := auth.NewTokenManager(
+		keys,
+		envOrDefault("JWT_ISSUER", defaultTokenIssuer),
+		envOrDefault("JWT_AUDIENCE", defaultTokenAudience),
+		accessTTL,
+		refreshTTL,
+	)
+	if err != nil {
+		log.Fatalf("Failed to initialize token manager: %v", err)
+	}
+
 	NewServer := &Server{
-		port: port,
-		db:   db,
+		port:             port,
+		fetchConcurrency: fetchConcurrency,
+		db:               db,
+		eth:              eth,
+		tokens:           tokens,
+		logins:           auth.NewLoginLimiter(auth.NewMemoryAttemptStore(), auth.DefaultUsernamePolicy, auth.DefaultIPPolicy),
+		siweDomain:       envOrDefault("SIWE_DOMAIN", fmt.Sprintf("localhost:%d", port)),
+		stuckTimeout:     stuckTimeout,
+		maxFeeBumps:      maxFeeBumps,
 		store: &Store{
 			transactionRepo:     repository.NewTransactionRepository(db.DB()),
+			transactionLogRepo:  repository.NewTransactionLogRepository(db.DB()),
+			contractABIRepo:     repository.NewContractABIRepository(db.DB()),
 			userRepo:            repository.NewUserRepository(db.DB()),
 			userTransactionRepo: repository.NewUserTransactionRepository(db.DB()),
+			refreshTokenRepo:    repository.NewRefreshTokenRepository(db.DB()),
+			revokedTokenRepo:    repository.NewRevokedTokenRepository(db.DB()),
+			apiKeyRepo:          repository.NewAPIKeyRepository(db.DB()),
+			walletRepo:          repository.NewWalletRepository(db.DB()),
+			siweNonceRepo:       repository.NewSIWENonceRepository(db.DB()),
+			loginAttemptRepo:    repository.NewLoginAttemptRepository(db.DB()),
+			outgoingRepo:        repository.NewOutgoingTransactionRepository(db.DB()),
+			accountNonceRepo:    repository.NewAccountNonceRepository(db.DB()),
 		},
 	}
 
+	// MAX_FEE_PER_GAS_GWEI and MAX_PRIORITY_FEE_PER_GAS_GWEI reject or cap fees when the network is expensive
+	if NewServer.feeCaps.MaxFeePerGas, err = parseUnits(envOrDefault("MAX_FEE_PER_GAS_GWEI", defaultMaxFeePerGasGwei), unitDecimals["gwei"]); err != nil {
+		log.Fatalf("Invalid MAX_FEE_PER_GAS_GWEI: %v", err)
+	}
+	if NewServer.feeCaps.MaxPriorityFeePerGas, err = parseUnits(envOrDefault("MAX_PRIORITY_FEE_PER_GAS_GWEI", defaultMaxPriorityFeePerGasGwei), unitDecimals["gwei"]); err != nil {
+		log.Fatalf("Invalid MAX_PRIORITY_FEE_PER_GAS_GWEI: %v", err)
+	}
+
+	// SIWE_CHAIN_ID defaults to the chain of the node
+	if NewServer.siweChainID, err = siweChainID(NewServer.eth); err != nil {
+		log.Fatalf("Failed to determine the Sign-In with Ethereum chain ID, set SIWE_CHAIN_ID: %v", err)
+	}
+
+	// SIGNER_TYPE selects how contract writes are signed: env (PRIVATE_KEY), keystore or remote
+	if NewServer.signer, err = newSigner(context.Background()); err != nil {
+		log.Fatalf("Failed to initialize signer: %v", err)
+	}
+	if NewServer.signer == nil {
+		log.Printf("Warning: no signer configured, contract writes are disabled")
+	}
+
+	NewServer.nonces = chain.NewNonceManager(NewServer.eth, nonceStore{repo: NewServer.store.accountNonceRepo, outgoingRepo: NewServer.store.outgoingRepo})
+	NewServer.syncNonces(context.Background())
+
+	// ADMIN_USERNAMES grants the admin role to existing users, to bootstrap role management
+	NewServer.bootstrapAdmins(context.Background(), os.Getenv("ADMIN_USERNAMES"))
+
+	NewServer.abis = newABIRegistry(NewServer.store.contractABIRepo)
+	if err := NewServer.abis.seed(context.Background(), os.Getenv("CONTRACT_ADDRESS")); err != nil {
+		log.Printf("Warning: failed to seed contract ABI: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	NewServer.cancel = cancel
+	NewServer.startWorker(ctx, func(ctx context.Context) {
+		NewServer.runReconciler(ctx, reconcileInterval)
+	})
+	NewServer.startWorker(ctx, func(ctx context.Context) {
+		NewServer.runTokenCleanup(ctx, tokenCleanupInterval)
+	})
+	NewServer.startWorker(ctx, func(ctx context.Context) {
+		NewServer.runOutgoingWatcher(ctx, reconcileInterval)
+	})
+
 	// Declare Server config
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%d", NewServer.port),
@@ -49,5 +248,75 @@ func NewServer() *http.Server {
 		WriteTimeout: 30 * time.Second,
 	}
 
-	return server
+	return server, NewServer
+}
+
+// siweChainID returns the chain ID set in SIWE_CHAIN_ID, or the one of the node if it is not set
+func siweChainID(eth chain.Backend) (int64, error) {
+	if value := os.Getenv("SIWE_CHAIN_ID"); value != "" {
+		return strconv.ParseInt(value, 10, 64)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	chainID, err := eth.ChainID(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return chainID.Int64(), nil
+}
+
+// envOrDefault returns the value of the environment variable, or fallback if it is not set
+func envOrDefault(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// bootstrapAdmins grants the admin role to the users in a comma-separated list of usernames.
+// Unknown usernames are skipped rather than reserved, so registering them later grants nothing.
+func (s *Server) bootstrapAdmins(ctx context.Context, usernames string) {
+	for _, username := range strings.Split(usernames, ",") {
+		username = strings.TrimSpace(username)
+		if username == "" {
+			continue
+		}
+
+		user, err := s.store.userRepo.GetByUsername(ctx, username)
+		if err != nil || user == nil {
+			log.Printf("Warning: cannot grant admin role to %q: user not found (%v)", username, err)
+			continue
+		}
+		if slices.Contains(user.Roles, auth.RoleAdmin) {
+			continue
+		}
+
+		if err := s.store.userRepo.UpdateRoles(ctx, user.ID, append(user.Roles, auth.RoleAdmin)); err != nil {
+			log.Printf("Warning: failed to grant admin role to %q: %v", username, err)
+			continue
+		}
+		log.Printf("Granted admin role to %q", username)
+	}
+}
+
+// startWorker runs fn in the background until the server is closed
+func (s *Server) startWorker(ctx context.Context, fn func(ctx context.Context)) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		fn(ctx)
+	}()
+}
+
+// Close stops the background workers and releases the Ethereum client and the database connection
+func (s *Server) Close() error {
+	s.cancel()
+	s.wg.Wait()
+
+	if remote, ok := s.signer.(*chain.RemoteSigner); ok {
+		remote.Close()
+	}
+	s.eth.Close()
+	return s.db.Close()
 }
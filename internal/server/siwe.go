@@ -0,0 +1,58 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"ethereum-fetcher-go/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// siweNonceTTL is how long a Sign-In with Ethereum nonce can be used
+const siweNonceTTL = 10 * time.Minute
+
+// siweRequest is the payload of the Sign-In with Ethereum endpoints
+type siweRequest struct {
+	Message   string `json:"message" binding:"required"`
+	Signature string `json:"signature" binding:"required"`
+}
+
+// verifySIWE parses and verifies the signed message of the request and consumes its nonce.
+// It responds with an error and returns nil if the message is not valid.
+func (s *Server) verifySIWE(c *gin.Context) *auth.SIWEMessage {
+	var request siweRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil
+	}
+
+	message, err := auth.ParseSIWEMessage(request.Message)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil
+	}
+
+	now := time.Now()
+	if err := message.Validate(s.siweDomain, s.siweChainID, now); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return nil
+	}
+	if err := auth.VerifySIWESignature(request.Message, request.Signature, message.Address); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return nil
+	}
+
+	// The nonce is consumed last, so requests with invalid signatures can't burn it
+	consumed, err := s.store.siweNonceRepo.Consume(c, message.Nonce, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil
+	}
+	if !consumed {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired nonce"})
+		return nil
+	}
+
+	return message
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"ethereum-fetcher-go/internal/auth"
+	"ethereum-fetcher-go/internal/models"
+)
+
+// tokenCleanupInterval is how often expired refresh tokens, revocations and nonces are removed
+const tokenCleanupInterval = time.Hour
+
+// TokenResponse is returned when a user authenticates or refreshes their tokens
+type TokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+	ExpiresIn    int    `json:"expiresIn"`
+}
+
+// newRefreshToken generates a refresh token for the user, returning its value and the model to store
+func (s *Server) newRefreshToken(userID int) (string, *models.RefreshToken, error) {
+	token, hash, expiresAt, err := s.tokens.NewRefreshToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	return token, &models.RefreshToken{
+		UserID:    userID,
+		TokenHash: hash,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// tokenResponse issues an access token for the user and pairs it with the refresh token
+func (s *Server) tokenResponse(user *models.User, refreshToken string) (*TokenResponse, error) {
+	accessToken, err := s.tokens.Issue(user.ID, user.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenResponse{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(s.tokens.AccessTTL().Seconds()),
+	}, nil
+}
+
+// issueTokens stores a new refresh token for the user and returns it with a new access token
+func (s *Server) issueTokens(ctx context.Context, user *models.User) (*TokenResponse, error) {
+	refreshToken, model, err := s.newRefreshToken(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.store.refreshTokenRepo.Create(ctx, model); err != nil {
+		return nil, err
+	}
+
+	return s.tokenResponse(user, refreshToken)
+}
+
+// revokeAccessToken adds the access token to the revocation list until it expires
+func (s *Server) revokeAccessToken(ctx context.Context, claims *auth.Claims) error {
+	return s.store.revokedTokenRepo.Revoke(ctx, claims.ID, claims.ExpiresAt.Time)
+}
+
+// runTokenCleanup periodically removes expired refresh tokens, revocations and nonces until ctx is cancelled
+func (s *Server) runTokenCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := time.Now()
+			if err := s.store.refreshTokenRepo.DeleteExpired(ctx, now); err != nil {
+				log.Printf("Warning: failed to delete expired refresh tokens: %v", err)
+			}
+			if err := s.store.revokedTokenRepo.DeleteExpired(ctx, now); err != nil {
+				log.Printf("Warning: failed to delete expired token revocations: %v", err)
+			}
+			if err := s.store.siweNonceRepo.DeleteExpired(ctx, now); err != nil {
+				log.Printf("Warning: failed to delete expired Sign-In with Ethereum nonces: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ethereum-fetcher-go/internal/auth"
+	"ethereum-fetcher-go/internal/models"
+	"ethereum-fetcher-go/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeUserRepo is a repository.UserRepository serving users from memory
+type fakeUserRepo struct {
+	repository.UserRepository
+	users map[int]*models.User
+}
+
+func (r *fakeUserRepo) GetByID(_ context.Context, id int) (*models.User, error) {
+	return r.users[id], nil
+}
+
+func (r *fakeUserRepo) UpdateRoles(_ context.Context, id int, roles []string) error {
+	r.users[id].Roles = roles
+	return nil
+}
+
+// fakeRefreshTokenRepo is a repository.RefreshTokenRepository storing tokens in memory
+type fakeRefreshTokenRepo struct {
+	repository.RefreshTokenRepository
+	tokens []*models.RefreshToken
+}
+
+func (r *fakeRefreshTokenRepo) Create(_ context.Context, token *models.RefreshToken) (*models.RefreshToken, error) {
+	token.ID = len(r.tokens) + 1
+	r.tokens = append(r.tokens, token)
+	return token, nil
+}
+
+func (r *fakeRefreshTokenRepo) GetByHash(_ context.Context, tokenHash string) (*models.RefreshToken, error) {
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			copied := *token
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeRefreshTokenRepo) Rotate(ctx context.Context, token *models.RefreshToken, replacement *models.RefreshToken) (bool, error) {
+	stored := r.tokens[token.ID-1]
+	if stored.RevokedAt != nil {
+		return false, nil
+	}
+	r.Create(ctx, replacement)
+	now := time.Now()
+	stored.RevokedAt, stored.ReplacedBy = &now, &replacement.ID
+	return true, nil
+}
+
+func (r *fakeRefreshTokenRepo) RevokeAllForUser(_ context.Context, userID int) error {
+	now := time.Now()
+	for _, token := range r.tokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func TestRefreshTokenHandler(t *testing.T) {
+	keys, err := auth.NewHMACKeySet("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := auth.NewTokenManager(keys, "issuer", "audience", time.Minute, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refreshTokenRepo := &fakeRefreshTokenRepo{}
+	s := &Server{
+		tokens: tokens,
+		store: &Store{
+			userRepo:         &fakeUserRepo{users: map[int]*models.User{1: {ID: 1, Username: "alice"}}},
+			refreshTokenRepo: refreshTokenRepo,
+		},
+	}
+	r := gin.New()
+	r.POST("/lime/refresh", s.refreshTokenHandler)
+
+	refresh := func(refreshToken string) (int, TokenResponse) {
+		body, _ := json.Marshal(refreshTokenRequest{RefreshToken: refreshToken})
+		req := httptest.NewRequest(http.MethodPost, "/lime/refresh", bytes.NewReader(body))
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		var response TokenResponse
+		json.Unmarshal(rr.Body.Bytes(), &response)
+		return rr.Code, response
+	}
+
+	issued, err := s.issueTokens(context.Background(), &models.User{ID: 1, Username: "alice"})
+	if err != nil {
+		t.Fatalf("issueTokens() returned error: %v", err)
+	}
+
+	status, rotated := refresh(issued.RefreshToken)
+	if status != http.StatusOK || rotated.Token == "" || rotated.RefreshToken == "" || rotated.RefreshToken == issued.RefreshToken {
+		t.Fatalf("expected a new token pair, got status %d and %+v", status, rotated)
+	}
+
+	// Reusing the rotated token is rejected and revokes the whole token family
+	if status, _ := refresh(issued.RefreshToken); status != http.StatusUnauthorized {
+		t.Errorf("expected reused refresh token to be rejected, got status %d", status)
+	}
+	if status, _ := refresh(rotated.RefreshToken); status != http.StatusUnauthorized {
+		t.Errorf("expected refresh tokens to be revoked after reuse, got status %d", status)
+	}
+
+	if status, _ := refresh("unknown"); status != http.StatusUnauthorized {
+		t.Errorf("expected unknown refresh token to be rejected, got status %d", status)
+	}
+}
+
+func (r *fakeUserRepo) GetByUsername(_ context.Context, username string) (*models.User, error) {
+	for _, user := range r.users {
+		if user.Username == username {
+			return user, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"ethereum-fetcher-go/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unitDecimals maps the supported denominations of wei amounts to their number of decimals
+var unitDecimals = map[string]int{
+	"wei":   0,
+	"gwei":  9,
+	"ether": 18,
+}
+
+// TransactionResponse is a transaction with its wei amounts formatted in the requested units
+type TransactionResponse struct {
+	*models.Transaction
+	// TransactionStatus is the receipt status, null until the transaction is mined,
+	// so pending transactions aren't mistaken for failed ones
+	TransactionStatus    *int   `json:"transactionStatus"`
+	Value                string `json:"value"`
+	GasPrice             string `json:"gasPrice"`
+	MaxFeePerGas         string `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas string `json:"maxPriorityFeePerGas"`
+	MaxFeePerBlobGas     string `json:"maxFeePerBlobGas"`
+	EffectiveGasPrice    string `json:"effectiveGasPrice"`
+	BlobGasPrice         string `json:"blobGasPrice"`
+	Fee                  string `json:"fee"`
+
+	DecodedInput  *DecodedCall   `json:"decodedInput,omitempty"`
+	DecodedEvents []DecodedEvent `json:"decodedEvents,omitempty"`
+}
+
+// transactionResponses formats the transactions in the units requested by the client
+// and decodes their input and events
+func (s *Server) transactionResponses(c *gin.Context, transactions []*models.Transaction) []TransactionResponse {
+	responses := newTransactionResponses(transactions, c.GetString("units"))
+	s.decodeTransactions(c.Request.Context(), responses)
+	return responses
+}
+
+// newTransactionResponses formats the wei amounts of the transactions in the given units
+func newTransactionResponses(transactions []*models.Transaction, units string) []TransactionResponse {
+	decimals := unitDecimals[units]
+
+	responses := make([]TransactionResponse, len(transactions))
+	for i, tx := range transactions {
+		responses[i] = TransactionResponse{
+			Transaction:          tx,
+			Value:                formatUnits(&tx.Value.Int, decimals),
+			GasPrice:             formatUnits(&tx.GasPrice.Int, decimals),
+			MaxFeePerGas:         formatUnits(&tx.MaxFeePerGas.Int, decimals),
+			MaxPriorityFeePerGas: formatUnits(&tx.MaxPriorityFeePerGas.Int, decimals),
+			MaxFeePerBlobGas:     formatUnits(&tx.MaxFeePerBlobGas.Int, decimals),
+			EffectiveGasPrice:    formatUnits(&tx.EffectiveGasPrice.Int, decimals),
+			BlobGasPrice:         formatUnits(&tx.BlobGasPrice.Int, decimals),
+			Fee:                  formatUnits(&tx.Fee.Int, decimals),
+		}
+		if tx.Status == models.TransactionStatusMined {
+			responses[i].TransactionStatus = &tx.TransactionStatus
+		}
+	}
+
+	return responses
+}
+
+// formatUnits formats a wei amount as a decimal string with the given number of decimals
+// without losing precision, e.g. 1500000000000000000 with 18 decimals becomes "1.5"
+func formatUnits(wei *big.Int, decimals int) string {
+	if decimals == 0 {
+		return wei.String()
+	}
+
+	base := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	whole, frac := new(big.Int).QuoRem(new(big.Int).Abs(wei), base, new(big.Int))
+
+	formatted := whole.String()
+	if frac.Sign() != 0 {
+		fracDigits := fmt.Sprintf("%0*s", decimals, frac.String())
+		formatted += "." + strings.TrimRight(fracDigits, "0")
+	}
+	if wei.Sign() < 0 {
+		formatted = "-" + formatted
+	}
+
+	return formatted
+}
+
+// parseUnits parses a non-negative decimal amount with the given number of decimals into wei,
+// e.g. "1.5" with 9 decimals becomes 1500000000
+func parseUnits(amount string, decimals int) (*big.Int, error) {
+	whole, frac, _ := strings.Cut(amount, ".")
+	if whole == "" && frac == "" || len(frac) > decimals {
+		return nil, fmt.Errorf("invalid amount %q", amount)
+	}
+
+	wei, ok := new(big.Int).SetString(whole+frac+strings.Repeat("0", decimals-len(frac)), 10)
+	if !ok || wei.Sign() < 0 {
+		return nil, fmt.Errorf("invalid amount %q", amount)
+	}
+	return wei, nil
+}
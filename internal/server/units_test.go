@@ -0,0 +1,59 @@
+package server
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestFormatUnits(t *testing.T) {
+	// 12345.678 ether, far beyond the range of an int64
+	wei, _ := new(big.Int).SetString("12345678000000000000000", 10)
+
+	tests := []struct {
+		units string
+		want  string
+	}{
+		{"wei", "12345678000000000000000"},
+		{"gwei", "12345678000000"},
+		{"ether", "12345.678"},
+	}
+
+	for _, tt := range tests {
+		if got := formatUnits(wei, unitDecimals[tt.units]); got != tt.want {
+			t.Errorf("formatUnits(%s) = %s, want %s", tt.units, got, tt.want)
+		}
+	}
+
+	if got := formatUnits(big.NewInt(1), unitDecimals["ether"]); got != "0.000000000000000001" {
+		t.Errorf("formatUnits(1 wei in ether) = %s, want 0.000000000000000001", got)
+	}
+}
+
+func TestParseUnits(t *testing.T) {
+	tests := []struct {
+		amount string
+		want   string
+	}{
+		{"30", "30000000000"},
+		{"1.5", "1500000000"},
+		{"0.000000001", "1"},
+		{".5", "500000000"},
+	}
+
+	for _, tt := range tests {
+		got, err := parseUnits(tt.amount, unitDecimals["gwei"])
+		if err != nil {
+			t.Errorf("parseUnits(%s) returned error: %v", tt.amount, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("parseUnits(%s) = %s, want %s", tt.amount, got, tt.want)
+		}
+	}
+
+	for _, amount := range []string{"", "abc", "-1", "0.0000000001", "1.2.3"} {
+		if _, err := parseUnits(amount, unitDecimals["gwei"]); err == nil {
+			t.Errorf("expected error for %q", amount)
+		}
+	}
+}